@@ -0,0 +1,112 @@
+package atomiccache
+
+// requestBuffer contains unattended cache set requests. It works in one of two
+// modes:
+//
+// Error mode (default) - buffer is a slice which holds up to limit + 1
+// requests. If it is full, new request is rejected and Set returns
+// ErrFullMemory.
+//
+// Lossy mode (circular) - buffer is a ring of fixed length. Enqueue and
+// dequeue are O(1). If it is full, new request overwrites the oldest one, so
+// Set never returns ErrFullMemory, but the oldest request is lost.
+//
+// If limit is 0, buffer is disabled in both modes and every request is
+// rejected.
+type requestBuffer struct {
+	items    []BufferItem
+	circular bool
+	limit    int
+	head     int
+	size     int
+}
+
+// newRequestBuffer returns buffer in error mode or circular buffer in lossy
+// mode. Capacity of circular buffer is equal to limit.
+func newRequestBuffer(limit uint32, circular bool) requestBuffer {
+	if circular {
+		return requestBuffer{items: make([]BufferItem, limit), circular: true, limit: int(limit)}
+	}
+
+	return requestBuffer{limit: int(limit)}
+}
+
+// push adds request to buffer. If the buffer is full, second value is true and
+// first value is request which was dropped (the new one in error mode, the
+// oldest one in lossy mode).
+func (b *requestBuffer) push(item BufferItem) (BufferItem, bool) {
+	if b.disabled() {
+		return item, true
+	}
+
+	if !b.circular {
+		if len(b.items) > b.limit {
+			return item, true
+		}
+		b.items = append(b.items, item)
+		return BufferItem{}, false
+	}
+
+	if len(b.items) == 0 {
+		return item, true
+	}
+
+	if b.size == len(b.items) {
+		dropped := b.items[b.head]
+		b.items[b.head] = item
+		b.head = (b.head + 1) % len(b.items)
+		return dropped, true
+	}
+
+	b.items[(b.head+b.size)%len(b.items)] = item
+	b.size++
+
+	return BufferItem{}, false
+}
+
+// disabled returns true if buffer rejects all requests.
+func (b *requestBuffer) disabled() bool {
+	return b.limit == 0
+}
+
+// len returns number of requests in buffer.
+func (b *requestBuffer) len() int {
+	if b.circular {
+		return b.size
+	}
+
+	return len(b.items)
+}
+
+// drain removes all requests from buffer and returns them in insertion order.
+func (b *requestBuffer) drain() []BufferItem {
+	if !b.circular {
+		items := b.items
+		b.items = nil
+		return items
+	}
+
+	items := make([]BufferItem, b.size)
+	for i := range items {
+		index := (b.head + i) % len(b.items)
+		items[i], b.items[index] = b.items[index], BufferItem{}
+	}
+	b.head, b.size = 0, 0
+
+	return items
+}
+
+// reset removes all requests from buffer.
+func (b *requestBuffer) reset() {
+	b.drain()
+}
+
+// BufferLen returns number of unattended set requests in buffer. Growing
+// buffer means that cache memory is full and garbage collection cannot release
+// enough records.
+func (a *AtomicCache) BufferLen() int {
+	a.RLock()
+	defer a.RUnlock()
+
+	return a.buffer.len()
+}
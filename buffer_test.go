@@ -0,0 +1,113 @@
+package atomiccache
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRequestBufferError(t *testing.T) {
+	buffer := newRequestBuffer(1, false)
+
+	for i := 0; i < 2; i++ {
+		if _, full := buffer.push(BufferItem{Key: []byte{byte(i)}}); full {
+			t.Errorf("Buffer is full after %v items", i)
+		}
+	}
+
+	if dropped, full := buffer.push(BufferItem{Key: []byte{2}}); !full || !reflect.DeepEqual(dropped.Key, []byte{2}) {
+		t.Errorf("Expecting new item to be dropped")
+	}
+	if buffer.len() != 2 {
+		t.Errorf("%v != %v", buffer.len(), 2)
+	}
+}
+
+func TestRequestBufferCircular(t *testing.T) {
+	buffer := newRequestBuffer(2, true)
+
+	for i := 0; i < 2; i++ {
+		if _, full := buffer.push(BufferItem{Key: []byte{byte(i)}}); full {
+			t.Errorf("Buffer is full after %v items", i)
+		}
+	}
+
+	if dropped, full := buffer.push(BufferItem{Key: []byte{2}}); !full || !reflect.DeepEqual(dropped.Key, []byte{0}) {
+		t.Errorf("Expecting the oldest item to be dropped")
+	}
+
+	items := buffer.drain()
+	expected := []BufferItem{{Key: []byte{1}}, {Key: []byte{2}}}
+	if !reflect.DeepEqual(items, expected) {
+		t.Errorf("%v != %v", items, expected)
+	}
+	if buffer.len() != 0 {
+		t.Errorf("%v != %v", buffer.len(), 0)
+	}
+}
+
+func TestCacheCircularBuffer(t *testing.T) {
+	cache := New(OptionMaxRecords(1), OptionMaxShardsSmall(1), OptionCircularBuffer(true))
+
+	for i := 0; i < 10; i++ {
+		if err := cache.Set([]byte{'k', byte(i)}, []byte("data"), 0); err != nil {
+			t.Errorf("Set error: %s", err.Error())
+		}
+	}
+	cache.WaitForGC()
+
+	if stats := cache.Stats(); stats.BufferLen > 1 {
+		t.Errorf("Buffer length %v is over capacity", stats.BufferLen)
+	}
+}
+
+func TestCacheOnBufferFull(t *testing.T) {
+	var dropped [][]byte
+
+	cache := New(OptionMaxRecords(1), OptionMaxShardsSmall(1), OptionGcStarter(1000), OptionCircularBuffer(true), OptionOnBufferFull(func(item BufferItem) {
+		dropped = append(dropped, item.Key)
+	}))
+
+	cache.Lock()
+	for i := 0; i < 4; i++ {
+		cache.setLocked([]byte{byte(i)}, []byte("data"), 0, cache.getExprTime(0))
+	}
+	cache.Unlock()
+
+	if cache.BufferLen() != 1 {
+		t.Errorf("%v != %v", cache.BufferLen(), 1)
+	}
+
+	expected := [][]byte{{1}, {2}}
+	if !reflect.DeepEqual(dropped, expected) {
+		t.Errorf("%v != %v", dropped, expected)
+	}
+}
+
+func TestCacheMaxBufferSize(t *testing.T) {
+	cache := New(OptionMaxRecords(1), OptionMaxShardsSmall(1), OptionGcStarter(1000), OptionMaxBufferSize(2))
+
+	cache.Lock()
+	for i := 0; i < 5; i++ {
+		cache.setLocked([]byte{byte(i)}, []byte("data"), 0, cache.getExprTime(0))
+	}
+	cache.Unlock()
+
+	if cache.BufferLen() != 3 {
+		t.Errorf("%v != %v", cache.BufferLen(), 3)
+	}
+}
+
+func TestCacheBufferDisabled(t *testing.T) {
+	for _, circular := range []bool{false, true} {
+		cache := New(OptionMaxRecords(1), OptionMaxShardsSmall(1), OptionMaxBufferSize(0), OptionCircularBuffer(circular))
+
+		cache.Set([]byte("key1"), []byte("data"), 0)
+		if err := cache.Set([]byte("key2"), []byte("data"), 0); !errors.Is(err, ErrFullMemory) {
+			t.Errorf("[%v] Expecting error 'ErrFullMemory'", circular)
+		}
+		if cache.BufferLen() != 0 {
+			t.Errorf("[%v] %v != %v", circular, cache.BufferLen(), 0)
+		}
+	}
+}
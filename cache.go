@@ -1,22 +1,39 @@
 package atomiccache
 
 import (
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
-
-	"github.com/emirpasic/gods/trees/btree"
 )
 
 // Internal cache errors
 var (
-	ErrNotFound   = errors.New("Record not found")
-	ErrDataLimit  = errors.New("Can't create new record, it violates data limit")
-	ErrFullMemory = errors.New("Can't create new rocord, memory is full")
+	ErrNotFound         = errors.New("Record not found")
+	ErrExpired          = errors.New("Record is expired")
+	ErrDataLimit        = errors.New("Can't create new record, it violates data limit")
+	ErrFullMemory       = errors.New("Can't create new rocord, memory is full")
+	ErrKeyTooLong       = errors.New("Key is longer than maximum key length")
+	ErrKeyTooShort      = errors.New("Key is shorter than minimum key length")
+	ErrChecksumMismatch = errors.New("Record checksum does not match")
+	ErrBufferTooSmall   = errors.New("Buffer is smaller than record")
+	ErrExpiredInput     = errors.New("Expiration time is not in the future")
+	ErrOutOfRange       = errors.New("Range is out of record data")
+	ErrReadOnly         = errors.New("Cache is in read-only mode")
+	ErrKeysSalted       = errors.New("Keys are salted and cannot be listed")
 )
 
-// Constans below are used for shard section identification.
+// Constans below are used for shard section identification. If custom tiers
+// are used, section ID is index of tier (sorted by size) increased by one.
 const (
 	// SMSH - Small Shards section
 	SMSH = iota + 1
@@ -26,6 +43,10 @@ const (
 	LGSH
 )
 
+// NoExpiry can be used as expiration duration of record, which is stored
+// permanently until it is deleted explicitly.
+const NoExpiry = -1 * time.Second
+
 // AtomicCache structure represents whole cache memory.
 type AtomicCache struct {
 	// RWMutex is used for access to shards array.
@@ -33,22 +54,43 @@ type AtomicCache struct {
 	// Deadlock mutex for debugging purpose.
 	// deadlock.RWMutex
 
-	// Lookup structure used for global index. It is based on BTree structure.
-	lookup *btree.Tree
+	// Options used for cache creation (see Clone).
+	options Options
+
+	// Source of time used for expiration of records.
+	clock Clock
+
+	// If read-only mode is enabled, methods which modify records return
+	// ErrReadOnly (see SetReadOnly).
+	readOnly atomic.Bool
+
+	// Lookup structure used for global index. It is based on hash map by
+	// default (see LookupBackend).
+	lookup LookupBackend
 
 	// Shards lookup tables which contains information about shards sections.
-	smallShards, mediumShards, largeShards ShardsLookup
+	// Sections are sorted by record size and section ID is index + 1.
+	sections []ShardsLookup
 
 	// Size of byte array used for memory allocation at small shard section.
 	RecordSizeSmall uint32
 	// Size of byte array used for memory allocation at medium shard section.
 	RecordSizeMedium uint32
 	// Size of byte array used for memory allocation at large shard section.
+	// It is also the maximum data size (unless maximum item size is set). If
+	// custom tiers are used, it contains
+	// size of the largest tier (small and medium contain size of the smallest
+	// and middle tier).
 	RecordSizeLarge uint32
 
 	// Maximum records per shard.
 	MaxRecords uint32
 
+	// Maximum data size and ID of section where data larger than
+	// RecordSizeLarge are stored (0 if there is no overflow section).
+	maxItemSize     uint32
+	overflowSection uint8
+
 	// Maximum small shards which can be allocated in cache memory.
 	MaxShardsSmall uint32
 	// Maximum medium shards which can be allocated in cache memory.
@@ -56,35 +98,192 @@ type AtomicCache struct {
 	// Maximum large shards which can be allocated in cache memory.
 	MaxShardsLarge uint32
 
-	// Garbage collector starter (run garbage collection every X memory sets).
-	GcStarter uint32
-	// Garbage collector counter for starter.
-	GcCounter uint32
+	// Garbage collector trigger (starter and counter).
+	gc gcTrigger
+	// Condition signalled when the last running garbage collection finishes
+	// (see WaitForGC).
+	gcWaitMutex sync.Mutex
+	gcWaitCond  *sync.Cond
+	// Semaphore which allows only one garbage collection at a time.
+	gcSem chan struct{}
 
 	// Buffer contains all unattended cache set requests. It has a maximum site
-	// which is equal to MaxRecords value.
-	buffer []BufferItem
+	// which is equal to MaxRecords value (see requestBuffer for modes).
+	buffer requestBuffer
+	// Function called for every request dropped because of full buffer. It is
+	// called while cache lock is held.
+	onBufferFull func(dropped BufferItem)
+
+	// Store CRC32 checksum with every record and verify it on Get.
+	checksums bool
+	// Memory pool used for shard allocation (disabled if nil).
+	pool *SharedPool
+
+	// Metrics recorder (NoopMetrics if not set).
+	metrics MetricsRecorder
+
+	// Allocate all shards at initialization (see OptionEagerAllocation).
+	eagerAllocation bool
+
+	// Garbage collection is started if utilization of shard section exceeds
+	// this ratio after Set (disabled if 0).
+	gcUtilizationThreshold float64
+
+	// Debug trace of operations (disabled if nil).
+	trace *debugTrace
+
+	// Counter section (disabled if nil). Counter values are updated
+	// atomically, index and free list are protected by the cache lock.
+	counters     []atomic.Int64
+	counterIndex map[string]counterRecord
+	counterFree  []uint32
+
+	// Current cache version. Records of different version are invalid.
+	version atomic.Uint32
+
+	// Garbage collector starter is adjusted after every garbage collection
+	// according to eviction rate (see OptionAdaptiveGC).
+	adaptiveGC bool
+
+	// Remaining slots of reservations by reservation ID and the last used
+	// reservation ID.
+	reservations  map[uint64]uint32
+	reservationID uint64
+
+	// Event bus which receives cache events (disabled if nil).
+	eventBus EventBus
+
+	// Channel which receives keys of records removed because of expiration
+	// (disabled if nil).
+	expirationNotify chan<- []byte
+
+	// Tags index maps tag name to set of keys and reverse index maps key to
+	// set of its tags, so tags of removed records can be pruned. Both are
+	// protected by cache lock.
+	tags    map[string]map[string]struct{}
+	keyTags map[string]map[string]struct{}
+
+	// Watchers registry maps key to channels of its watches. It has its own
+	// mutex, so it does not block the cache memory. Number of watches allows
+	// to skip the registry if nothing is watched.
+	watchers   map[string][]chan WatchEvent
+	watchMutex sync.Mutex
+	watchCount atomic.Int32
+	// Watch events collected under the write lock, they are sent after the
+	// lock is released (see Unlock).
+	watchEvents []WatchEvent
+
+	// Logger for garbage collection and buffer events. Logging is disabled if
+	// logger is nil.
+	logger *slog.Logger
+
+	// Statistics counters. Counters are never decreased, statistics are
+	// computed as difference from base snapshot (taken by StatsReset) or
+	// from checkpoint (see StatsSince).
+	stats            statsCounters
+	statsMutex       sync.Mutex
+	statsBase        statsSnapshot
+	statsCheckpoints []statsSnapshot
+
+	// If sliding expiration is enabled, every successful Get extends lifetime
+	// of record by its original expiration duration.
+	slidingExpiration bool
+
+	// Maximum random duration added to expiration time of every record. It
+	// prevents simultaneous expiration of records stored at the same time.
+	expirationJitter time.Duration
+	// Expiration duration used if record is stored with zero duration.
+	defaultTTL time.Duration
+
+	// Negative records (keys known to be missing in backing store) with their
+	// expiration time. They are stored separately from lookup table.
+	negativeKeys map[string]time.Time
+
+	// Loader is called on cache miss (read-through). Concurrent misses of the
+	// same key are deduplicated by loader group.
+	loader      func(ctx context.Context, key []byte) ([]byte, time.Duration, error)
+	loaderGroup flightGroup
+
+	// Concurrent GetOrSetCtx calls are deduplicated by this group.
+	getOrSetGroup flightGroup
+
+	// Records whose remaining TTL is lower than threshold (fraction of their
+	// original TTL) are reloaded by loader in background every prefetch
+	// interval (disabled if threshold is 0). Prefetch is stopped by Close.
+	prefetchThreshold float64
+	prefetchInterval  time.Duration
+	prefetchStop      chan struct{}
+	prefetchStopOnce  sync.Once
+
+	// L2 cache is used on Get miss and it is updated on Set (disabled if
+	// nil).
+	l2 Cache
+
+	// Store is called after every Set (write-through). Store errors are
+	// reported to onStoreError callback.
+	store        func(ctx context.Context, key, value []byte) error
+	onStoreError func(key []byte, err error)
+
+	// Minimum and maximum key length (maximum is not limited if 0).
+	minKeyLength int
+	maxKeyLength int
+
+	// Value validator is called before data are stored. If it returns error,
+	// data are not stored.
+	valueValidator func(key, value []byte) error
+
+	// Eviction policy selects record which is removed if shards section is
+	// full. If it is nil, new records are stored to buffer instead. Access
+	// statistics of records are tracked only if policy is set.
+	evictionPolicy EvictionPolicy
+
+	// If tier promotion is enabled, records which do not fit to full section
+	// are stored to larger section and records accessed more than threshold
+	// times are moved back to the smallest section where they fit.
+	tierPromotion          bool
+	tierPromotionThreshold uint32
 }
 
 // ShardsLookup represents data structure for for each shards section. In each
 // section we have different size of records in that shards.
 type ShardsLookup struct {
+	// Section identifier.
+	id uint8
+	// Size of record in section shards.
+	recordSize uint32
+	// Number of records in section shards.
+	slotCount uint32
+	// Maximum shards which can be allocated in section.
+	maxShards uint32
 	// Array of pointers to shard objects.
 	shards []*Shard
 	// Array of shard indexes which are currently active.
 	shardsActive []uint32
 	// Array of shard indexes which are currently available for new allocation.
 	shardsAvail []uint32
+	// Number of slots reserved for reservation holders (see Reserve).
+	reserved uint32
+	// Source of time of cache memory (used by eviction policies).
+	clock Clock
 }
 
 // LookupRecord represents item in lookup table. One record contains index of
 // shard and record. So we can determine which shard access and which record of
-// shard to get. Record also contains expiration time.
+// shard to get. Record also contains expiration time, original expiration
+// duration (used for sliding expiration), time of creation, access statistics
+// (used by eviction policies) and cache version of the record. Original key is
+// stored only if keys are hashed (see HashedLookup).
 type LookupRecord struct {
 	RecordIndex  uint32
 	ShardIndex   uint32
 	ShardSection uint8
 	Expiration   time.Time
+	LastAccess   time.Time
+	OriginalTTL  time.Duration
+	Created      time.Time
+	AccessCount  uint32
+	Version      uint32
+	Key          string
 }
 
 // BufferItem is used for buffer, which contains all unattended cache set
@@ -95,8 +294,22 @@ type BufferItem struct {
 	Expire time.Duration
 }
 
-// New initialize whole cache memory with one allocated shard.
+// New initialize whole cache memory with one allocated shard. It panics if
+// options are not valid (see NewWithError).
 func New(opts ...Option) *AtomicCache {
+	cache, err := NewWithError(opts...)
+	if err != nil {
+		panic(err)
+	}
+
+	return cache
+}
+
+// NewWithError initialize whole cache memory with one allocated shard. If
+// options are not valid, then error is returned. Record sizes must be ordered
+// (small < medium < large), small record size must be at least MinRecordSize
+// and large record size must be at most MaxRecordSize.
+func NewWithError(opts ...Option) (*AtomicCache, error) {
 	var options = &Options{
 		RecordSizeSmall:  512,
 		RecordSizeMedium: 2048,
@@ -106,41 +319,149 @@ func New(opts ...Option) *AtomicCache {
 		MaxShardsMedium:  128,
 		MaxShardsLarge:   64,
 		GcStarter:        25000,
+		MinKeyLength:     1,
+		DefaultTTL:       48 * time.Hour,
+		MaxBufferSize:    unsetBufferSize,
+		PrefetchInterval: time.Minute,
+
+		TierPromotionThreshold: 10,
 	}
 
 	for _, opt := range opts {
 		opt(options)
 	}
 
+	if err := options.validate(); err != nil {
+		return nil, err
+	}
+
 	// Init cache structure
 	cache := &AtomicCache{}
+	cache.options = *options
+	cache.clock = options.Clock
+	cache.readOnly.Store(options.ReadOnly)
+	if cache.clock == nil {
+		cache.clock = RealClock{}
+	}
 
 	// Init lookup table
-	cache.lookup = btree.NewWithStringComparator(3)
+	cache.lookup = options.LookupBackend
+	if cache.lookup == nil && options.BTreeDegree != 0 {
+		cache.lookup = NewBTreeLookup(options.BTreeDegree)
+	} else if cache.lookup == nil {
+		cache.lookup = NewHashmapLookup()
+	}
+	if options.KeySalt == nil && options.HashKeys && options.HashSeed != 0 {
+		cache.lookup = NewHashedLookupSeed(cache.lookup, options.HashSeed)
+	} else if options.KeySalt == nil && options.HashKeys {
+		cache.lookup = NewHashedLookup(cache.lookup)
+	}
+	if options.KeySalt != nil {
+		cache.lookup = NewSaltedLookup(cache.lookup, options.KeySalt)
+	}
 
-	// Init small shards section
-	initShardsSection(&cache.smallShards, options.MaxShardsSmall, options.MaxRecords, options.RecordSizeSmall)
-	initShardsSection(&cache.mediumShards, options.MaxShardsMedium, options.MaxRecords, options.RecordSizeMedium)
-	initShardsSection(&cache.largeShards, options.MaxShardsLarge, options.MaxRecords, options.RecordSizeLarge)
+	// Init tags index
+	cache.tags = make(map[string]map[string]struct{})
+	cache.keyTags = make(map[string]map[string]struct{})
+	cache.watchers = make(map[string][]chan WatchEvent)
+
+	// Init negative records
+	cache.negativeKeys = make(map[string]time.Time)
 
 	// Define setup values
-	cache.RecordSizeSmall = options.RecordSizeSmall
-	cache.RecordSizeMedium = options.RecordSizeMedium
-	cache.RecordSizeLarge = options.RecordSizeLarge
+	tiers := options.tiers()
+	cache.RecordSizeSmall = tiers[0].MaxSize
+	cache.RecordSizeMedium = tiers[len(tiers)/2].MaxSize
+	cache.RecordSizeLarge = tiers[len(tiers)-1].MaxSize
 	cache.MaxRecords = options.MaxRecords
+	if options.MaxBufferSize == unsetBufferSize {
+		options.MaxBufferSize = options.MaxRecords
+	}
+	cache.buffer = newRequestBuffer(options.MaxBufferSize, options.CircularBuffer)
+	cache.onBufferFull = options.OnBufferFull
+	cache.checksums = options.Checksums
 	cache.MaxShardsSmall = options.MaxShardsSmall
 	cache.MaxShardsMedium = options.MaxShardsMedium
 	cache.MaxShardsLarge = options.MaxShardsLarge
-	cache.GcStarter = options.GcStarter
+	cache.gc.starter.Store(options.GcStarter)
+	cache.gcSem = make(chan struct{}, 1)
+	cache.gcWaitCond = sync.NewCond(&cache.gcWaitMutex)
+	cache.statsBase = statsSnapshot{at: cache.clock.Now()}
+	cache.statsCheckpoints = []statsSnapshot{cache.statsBase}
+	cache.logger = options.Logger
+	cache.slidingExpiration = options.SlidingExpiration
+	cache.expirationJitter = options.ExpirationJitter
+	cache.defaultTTL = options.DefaultTTL
+	cache.version.Store(options.Version)
+	cache.expirationNotify = options.ExpirationNotify
+	cache.adaptiveGC = options.AdaptiveGC
+	cache.eventBus = options.EventBus
+	cache.loader = options.Loader
+	cache.store = options.Store
+	cache.l2 = options.L2Cache
+	cache.onStoreError = options.OnStoreError
+	cache.minKeyLength = options.MinKeyLength
+	cache.maxKeyLength = options.MaxKeyLength
+	cache.valueValidator = options.ValueValidator
+	cache.evictionPolicy = options.EvictionPolicy
+	cache.tierPromotion = options.TierPromotion
+	cache.tierPromotionThreshold = options.TierPromotionThreshold
+	cache.pool = options.Pool
+	cache.eagerAllocation = options.EagerAllocation
+	cache.gcUtilizationThreshold = options.GcUtilizationThreshold
+	if options.AtomicCounters > 0 {
+		cache.initCounters(options.AtomicCounters)
+	}
+	if options.DebugTrace != nil {
+		cache.trace = &debugTrace{w: options.DebugTrace}
+	}
+	cache.metrics = options.Metrics
+	if cache.metrics == nil {
+		cache.metrics = NoopMetrics{}
+	}
 
-	return cache
+	// Init shards sections. Overflow section contains shards with one record
+	// of maximum item size.
+	cache.maxItemSize = cache.RecordSizeLarge
+	if options.MaxItemSize != 0 {
+		cache.maxItemSize = options.MaxItemSize
+	}
+	if cache.maxItemSize > cache.RecordSizeLarge {
+		tiers = append(tiers, TierConfig{MaxSize: cache.maxItemSize, MaxShards: options.MaxShardsLarge})
+		cache.overflowSection = uint8(len(tiers))
+	}
+
+	cache.sections = make([]ShardsLookup, len(tiers))
+	for i, tier := range tiers {
+		cache.sections[i].id = uint8(i + 1)
+		cache.sections[i].slotCount = cache.MaxRecords
+		if cache.sections[i].id == cache.overflowSection {
+			cache.sections[i].slotCount = 1
+		}
+		cache.initShardsSection(&cache.sections[i], tier.MaxShards, tier.MaxSize)
+	}
+
+	cache.prefetchThreshold = options.PrefetchThreshold
+	cache.prefetchInterval = options.PrefetchInterval
+	cache.prefetchStop = make(chan struct{})
+	if cache.loader != nil && cache.prefetchThreshold > 0 {
+		go cache.runPrefetch()
+	}
+
+	return cache, nil
 }
 
 // initShardsSection provides shards sections initialization. So the cache has
-// one shard in each section at the begging.
-func initShardsSection(shardsSection *ShardsLookup, maxShards, maxRecords, recordSize uint32) {
+// one shard in each section at the begging. Memory of previous shards is
+// returned to shared pool (if it is set).
+func (a *AtomicCache) initShardsSection(shardsSection *ShardsLookup, maxShards, recordSize uint32) {
 	var shardIndex uint32
 
+	for _, shard := range shardsSection.shards {
+		a.freeShard(shard)
+	}
+
+	*shardsSection = ShardsLookup{id: shardsSection.id, recordSize: recordSize, slotCount: shardsSection.slotCount, maxShards: maxShards, reserved: shardsSection.reserved, clock: a.clock}
 	shardsSection.shards = make([]*Shard, maxShards, maxShards)
 	for i := uint32(0); i < maxShards; i++ {
 		shardsSection.shardsAvail = append(shardsSection.shardsAvail, i)
@@ -148,250 +469,1649 @@ func initShardsSection(shardsSection *ShardsLookup, maxShards, maxRecords, recor
 
 	shardIndex, shardsSection.shardsAvail = shardsSection.shardsAvail[0], shardsSection.shardsAvail[1:]
 	shardsSection.shardsActive = append(shardsSection.shardsActive, shardIndex)
-	shardsSection.shards[shardIndex] = NewShard(maxRecords, recordSize)
+	shardsSection.shards[shardIndex] = a.allocShard(shardsSection)
+
+	// Eager allocation allocates all available shards, they are activated on
+	// demand, but never released.
+	if a.eagerAllocation {
+		for _, i := range shardsSection.shardsAvail {
+			shardsSection.shards[i] = a.allocShard(shardsSection)
+		}
+	}
+}
+
+// allocShard returns new shard for records of shards section. If shared pool
+// is set, shard memory is taken from the pool.
+func (a *AtomicCache) allocShard(shardsSection *ShardsLookup) *Shard {
+	return newShard(shardsSection.slotCount, shardsSection.recordSize, a.checksums, a.pool)
+}
+
+// freeShard returns memory of shard to shared pool (if it is set).
+func (a *AtomicCache) freeShard(shard *Shard) {
+	if a.pool != nil && shard != nil {
+		a.pool.put(shard.memory)
+	}
 }
 
 // Set store data to cache memory. If key/record is already in memory, then data
 // are replaced. If not, it checks if there are some allocated shard with empty
 // space for data. If there is no empty space, new shard is allocated. Otherwise
 // some valid record (FIFO queue) is deleted and new one is stored.
+//
+// If write-through store is set, data are also persisted by the store function
+// in separate goroutine (see SetCtx). Data can be empty (nil or zero-length),
+// such record represents presence of key without data.
 func (a *AtomicCache) Set(key []byte, data []byte, expire time.Duration) error {
-	if len(data) > int(a.RecordSizeLarge) {
-		return ErrDataLimit
+	return a.SetCtx(context.Background(), key, data, expire)
+}
+
+// SetCtx store data to cache memory same way as Set. If write-through store is
+// set, then data are persisted by the store function (with context on input)
+// in separate goroutine after they are stored to cache memory. Store errors
+// are reported to store error callback. If L2 cache is set, data are written
+// to it in separate goroutine too.
+func (a *AtomicCache) SetCtx(ctx context.Context, key []byte, data []byte, expire time.Duration) error {
+	if err := a.SetNoStore(key, data, expire); err != nil {
+		return err
 	}
 
-	new := false
-	collectGarbage := false
-	shardSection, shardSectionID := a.getShardsSectionBySize(len(data))
+	a.persist(ctx, key, data, expire)
 
-	a.Lock()
-	if ival, ok := a.lookup.Get(string(key)); !ok {
-		new = true
-	} else {
-		val := ival.(LookupRecord)
+	return nil
+}
 
-		if val.ShardSection != shardSectionID {
-			shardSection.shards[val.ShardIndex].Free(val.RecordIndex)
-			val.RecordIndex = shardSection.shards[val.ShardIndex].Set(data)
-			a.lookup.Put(string(key), LookupRecord{ShardIndex: val.ShardIndex, ShardSection: shardSectionID, RecordIndex: val.RecordIndex, Expiration: a.getExprTime(expire)})
-		} else {
-			prevShardSection := a.getShardsSectionByID(val.ShardSection)
-			prevShardSection.shards[val.ShardIndex].Free(val.RecordIndex)
-			new = true
-		}
+// SetAbsolute store data to cache memory same way as Set, but record expires
+// at specified time instead of duration relative to now (e.g. expiration time
+// received from upstream API). Default TTL and expiration jitter are not
+// applied. If expiration time is not in the future, ErrExpiredInput is
+// returned.
+func (a *AtomicCache) SetAbsolute(key []byte, data []byte, expiresAt time.Time) error {
+	expire := expiresAt.Sub(a.clock.Now())
+	if expire <= 0 {
+		return ErrExpiredInput
 	}
 
-	if new {
-		if si, ok := a.getShard(shardSectionID); ok {
-			ri := shardSection.shards[si].Set(data)
-			a.lookup.Put(string(key), LookupRecord{ShardIndex: si, ShardSection: shardSectionID, RecordIndex: ri, Expiration: a.getExprTime(expire)})
-		} else if si, ok := a.getEmptyShard(shardSectionID); ok {
-			shardSection.shards[si] = NewShard(a.MaxRecords, a.getRecordSizeByShardSectionID(shardSectionID))
-			ri := shardSection.shards[si].Set(data)
-			a.lookup.Put(string(key), LookupRecord{ShardIndex: si, ShardSection: shardSectionID, RecordIndex: ri, Expiration: a.getExprTime(expire)})
-		} else {
-			if len(a.buffer) <= int(a.MaxRecords) {
-				a.buffer = append(a.buffer, BufferItem{Key: key, Data: data, Expire: expire})
-			} else {
-				a.Unlock()
-				return ErrFullMemory
+	if err := a.setNoStore(key, data, expire, expiresAt, nil); err != nil {
+		return err
+	}
+
+	a.persist(context.Background(), key, data, expire)
+
+	return nil
+}
+
+// persist sends copy of data to write-through store and L2 cache (if they are
+// set) in separate goroutines.
+func (a *AtomicCache) persist(ctx context.Context, key []byte, data []byte, expire time.Duration) {
+	if a.store != nil {
+		key, data := append([]byte(nil), key...), append([]byte(nil), data...)
+		go func() {
+			if err := a.store(ctx, key, data); err != nil && a.onStoreError != nil {
+				a.onStoreError(key, err)
+			}
+		}()
+	}
+
+	if a.l2 != nil {
+		key, data := append([]byte(nil), key...), append([]byte(nil), data...)
+		go func() {
+			if err := a.l2.Set(key, data, expire); err != nil && a.logger != nil {
+				a.logger.Warn("atomiccache: L2 cache set failed", "error", err)
 			}
+		}()
+	}
+}
+
+// SetNoStore store data to cache memory same way as Set, but write-through
+// store is bypassed. If value validator is set, it is called before the lock is
+// acquired and its error is returned.
+func (a *AtomicCache) SetNoStore(key []byte, data []byte, expire time.Duration) error {
+	return a.setNoStore(key, data, expire, a.getExprTime(expire), nil)
+}
+
+// setNoStore store data to cache memory with specified expiration time. The
+// expire duration is kept as original TTL of record. If record is stored to
+// cache memory (not to buffer), the key is registered for tags on input under
+// the same lock.
+func (a *AtomicCache) setNoStore(key []byte, data []byte, expire time.Duration, expiration time.Time, tags []string) error {
+	if err := a.checkKey(key); err != nil {
+		return err
+	}
+
+	if len(data) > int(a.maxItemSize) {
+		return &DataLimitError{Key: append([]byte(nil), key...), Size: len(data), Limit: int(a.maxItemSize)}
+	}
 
-			collectGarbage = true
+	if a.valueValidator != nil {
+		if err := a.valueValidator(key, data); err != nil {
+			return err
 		}
 	}
+
+	start := time.Now()
+	if err := a.lockWritable(); err != nil {
+		return err
+	}
+	collectGarbage, err := a.setLocked(key, data, expire, expiration)
+	if err == nil && !collectGarbage {
+		a.tagLocked(string(key), tags)
+	}
+	var bufferLen int
+	if err == ErrFullMemory {
+		bufferLen = a.buffer.len()
+	}
+	overUtilized := false
+	if err == nil && a.gcUtilizationThreshold > 0 {
+		_, shardSectionID := a.getShardsSectionBySize(len(data))
+		overUtilized = a.utilizationLocked(shardSectionID) > a.gcUtilizationThreshold
+	}
+	var val LookupRecord
+	if a.trace != nil || a.eventBus != nil {
+		val, _ = a.lookup.Get(string(key))
+	}
 	a.Unlock()
+	a.metrics.RecordSetLatency(time.Since(start))
+	a.traceOp("set", key, err, val)
+	a.emitEvent(EventSet, key, val.ShardSection, err)
+
+	if err == ErrFullMemory {
+		return &FullMemoryError{BufferLen: bufferLen, BufferCap: a.buffer.limit}
+	} else if err != nil {
+		return err
+	}
 
-	if (atomic.AddUint32(&a.GcCounter, 1) == a.GcStarter) || collectGarbage {
-		atomic.StoreUint32(&a.GcCounter, 0)
-		go a.collectGarbage()
+	// Utilization trigger does not start another garbage collection if one is
+	// already running, otherwise every Set of full section would start one.
+	if a.gc.tick() || collectGarbage || (overUtilized && a.gc.running.Load() == 0) {
+		a.gc.counter.Store(0)
+		a.startGarbageCollection()
 	}
 
 	return nil
 }
 
-// Get returns list of bytes if record is present in cache memory. If record is
-// not found, then error is returned and list is nil.
-func (a *AtomicCache) Get(key []byte) ([]byte, error) {
-	var result []byte
-	var hit = false
-
-	a.RLock()
-	if ival, ok := a.lookup.Get(string(key)); ok {
-		val := ival.(LookupRecord)
-		shardSection := a.getShardsSectionByID(val.ShardSection)
+// setLocked store data to cache memory with specified expiration time. The
+// expire duration is kept as original TTL of record. It returns true if data
+// were stored to buffer and garbage collection is required.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) setLocked(key []byte, data []byte, expire time.Duration, expiration time.Time) (bool, error) {
+	if a.storeLocked(key, data, expire, expiration) {
+		return false, nil
+	}
 
-		if shardSection.shards[val.ShardIndex] != nil && time.Now().Before(val.Expiration) {
-			result = shardSection.shards[val.ShardIndex].Get(val.RecordIndex)
-			hit = true
+	// Caller may reuse key and data, so buffer keeps their copies.
+	if dropped, full := a.buffer.push(BufferItem{Key: append([]byte(nil), key...), Data: append([]byte(nil), data...), Expire: expire}); full {
+		if a.onBufferFull != nil {
+			a.onBufferFull(dropped)
+		}
+		if !a.buffer.circular || a.buffer.disabled() {
+			return false, ErrFullMemory
 		}
 	}
-	a.RUnlock()
-
-	if hit {
-		return result, nil
+	if a.logger != nil && a.buffer.len() > a.buffer.limit/2 {
+		a.logger.Warn("atomiccache: buffer is over 50% of capacity", "buffer_len", a.buffer.len(), "buffer_cap", a.buffer.limit)
 	}
 
-	return nil, ErrNotFound
+	return true, nil
 }
 
-// releaseShard release shard if there is no record in memory. It returns true
-// if shard was released. The function requires the shard section ID and
-// shard ID on input.
+// storeLocked store data to counter section or to free slot of shard section
+// and replaces previous record of the key. It returns false if there is no
+// space left, data are not stored to buffer in that case.
 // This method is not thread safe and additional locks are required.
-func (a *AtomicCache) releaseShard(shardSectionID uint8, shard uint32) bool {
-	var shardSection *ShardsLookup
+func (a *AtomicCache) storeLocked(key []byte, data []byte, expire time.Duration, expiration time.Time) bool {
+	_, shardSectionID := a.getShardsSectionBySize(len(data))
 
-	if shardSection = a.getShardsSectionByID(shardSectionID); shardSection == nil {
+	if len(a.negativeKeys) != 0 {
+		delete(a.negativeKeys, string(key))
+	}
+
+	// Integer values are stored to counter section (if it is not full)
+	if a.counters != nil {
+		if value, ok := parseCounter(data); ok && a.setCounterLocked(string(key), value, expire, expiration) {
+			a.watchLocked(EventSet, string(key), data)
+			return true
+		}
+		a.deleteCounterLocked(string(key))
+	}
+
+	// Record index is valid only in section where record was stored, so
+	// previous record is removed from its own section (including release of
+	// emptied shard) and new record is allocated afterwards.
+	if val, ok := a.lookup.Get(string(key)); ok {
+		a.freeLocked(string(key), val)
+	}
+
+	si, ri, ok := a.allocLocked(shardSectionID, data)
+	if !ok && a.tierPromotion {
+		shardSectionID, si, ri, ok = a.allocLargerLocked(shardSectionID, data)
+	}
+	if !ok && a.evictLocked(shardSectionID) {
+		si, ri, ok = a.allocLocked(shardSectionID, data)
+	}
+	if !ok {
 		return false
 	}
 
-	if shardSection.shards[shard].IsEmpty() == true {
-		shardSection.shards[shard] = nil
+	a.putLocked(string(key), LookupRecord{ShardIndex: si, ShardSection: shardSectionID, RecordIndex: ri, Expiration: expiration, LastAccess: a.clock.Now(), OriginalTTL: expire, Created: a.clock.Now(), Version: a.version.Load()})
+	a.watchLocked(EventSet, string(key), data)
 
-		shardSection.shardsAvail = append(shardSection.shardsAvail, shard)
-		for k, v := range shardSection.shardsActive {
-			if v == shard {
-				shardSection.shardsActive = append(shardSection.shardsActive[:k], shardSection.shardsActive[k+1:]...)
-				break
-			}
-		}
+	return true
+}
 
-		return true
+// allocLocked stores data to shard of specified section which has available
+// space. If there is no such shard, new shard is allocated. It returns shard
+// index and record index. Third value is false if there is no space left.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) allocLocked(shardSectionID uint8, data []byte) (uint32, uint32, bool) {
+	si, ok := a.allocShardLocked(shardSectionID)
+	if !ok {
+		return 0, 0, false
 	}
 
-	return false
+	return si, a.getShardsSectionByID(shardSectionID).shards[si].Set(data), true
 }
 
-// getShard return index of shard which have some available space for new
-// record. If there is no shard with available space, then false is returned as
-// a second value. The function requires the shard section ID on input.
+// allocShardLocked returns index of shard of specified section which has
+// available space. If there is no such shard, new shard is allocated. Second
+// value is false if there is no space left (except reserved slots).
 // This method is not thread safe and additional locks are required.
-func (a *AtomicCache) getShard(shardSectionID uint8) (uint32, bool) {
-	var shardSection *ShardsLookup
+func (a *AtomicCache) allocShardLocked(shardSectionID uint8) (uint32, bool) {
+	shardSection := a.getShardsSectionByID(shardSectionID)
 
-	if shardSection = a.getShardsSectionByID(shardSectionID); shardSection == nil {
+	// Reserved slots are kept free for reservation holders
+	if shardSection.reserved > 0 && a.freeSlotsLocked(shardSection) <= shardSection.reserved {
 		return 0, false
 	}
 
-	for _, shardIndex := range shardSection.shardsActive {
-		if shardSection.shards[shardIndex].GetSlotsAvail() != 0 {
-			return shardIndex, true
+	if si, ok := a.getShard(shardSectionID); ok {
+		return si, true
+	} else if si, ok := a.getEmptyShard(shardSectionID); ok {
+		if shardSection.shards[si] == nil {
+			shardSection.shards[si] = a.allocShard(shardSection)
 		}
+		return si, true
 	}
 
 	return 0, false
 }
 
-// getEmptyShard return index of shard that can be used for new shard
-// allocation. If there is no left index, then false is returned as a second
-// value. The function requires the shard section ID on input.
-// This method is not thread safe and additional locks are required.
-func (a *AtomicCache) getEmptyShard(shardSectionID uint8) (uint32, bool) {
-	var shardSection *ShardsLookup
+// Update modifies data of record by function on input. The function gets copy
+// of current data and returns new data. The write lock is held for the whole
+// duration of the function, so there is no race between read and write. If
+// the function returns error, data are not modified and the error is
+// returned. Expiration time of record is kept. If record is not found, then
+// error is returned.
+func (a *AtomicCache) Update(key []byte, fn func(current []byte) ([]byte, error)) error {
+	if err := a.checkKey(key); err != nil {
+		return err
+	}
 
-	if shardSection = a.getShardsSectionByID(shardSectionID); shardSection == nil {
-		return 0, false
+	if err := a.lockWritable(); err != nil {
+		return err
 	}
 
-	if len(shardSection.shardsAvail) == 0 {
-		return 0, false
+	current, val, err := a.getLocked(key)
+	if err != nil {
+		a.Unlock()
+		return err
 	}
+	current = append([]byte(nil), current...)
 
-	var shardIndex uint32
-	shardIndex, shardSection.shardsAvail = shardSection.shardsAvail[0], shardSection.shardsAvail[1:]
-	shardSection.shardsActive = append(shardSection.shardsActive, shardIndex)
+	data, err := fn(current)
+	if err != nil {
+		a.Unlock()
+		return err
+	}
+	if len(data) > int(a.maxItemSize) {
+		a.Unlock()
+		return ErrDataLimit
+	}
 
-	return shardIndex, true
+	if a.valueValidator != nil {
+		if err := a.valueValidator(key, data); err != nil {
+			a.Unlock()
+			return err
+		}
+	}
+
+	collectGarbage, err := a.setLocked(key, data, val.OriginalTTL, val.Expiration)
+	a.Unlock()
+
+	if collectGarbage {
+		a.startGarbageCollection()
+	}
+
+	return err
 }
 
-// getShardsSectionBySize returns shards section lookup structure and section
-// identifier as a second value. The function requires the data size value on
-// input. If data are bigger than allowed value, then nil and 0 is returned.
-// This method is not thread safe and additional locks are required.
-func (a *AtomicCache) getShardsSectionBySize(dataSize int) (*ShardsLookup, uint8) {
-	if dataSize <= int(a.RecordSizeSmall) {
-		return &a.smallShards, uint8(SMSH)
-	} else if dataSize > int(a.RecordSizeSmall) && dataSize <= int(a.RecordSizeMedium) {
-		return &a.mediumShards, uint8(MDSH)
-	} else if dataSize > int(a.RecordSizeMedium) && dataSize <= int(a.RecordSizeLarge) {
-		return &a.largeShards, uint8(LGSH)
+// Get returns list of bytes if record is present in cache memory. If record is
+// not found, then error is returned and list is nil. If loader is set, missing
+// record is loaded instead (see GetCtx). Zero-length data are valid record
+// (e.g. presence indicator of key), Get returns non-nil empty list for it.
+func (a *AtomicCache) Get(key []byte) ([]byte, error) {
+	return a.GetCtx(context.Background(), key)
+}
+
+// GetCtx returns list of bytes if record is present in cache memory. If record
+// is not found and loader is set, then the loader is called with context on
+// input, its result is stored and returned. Concurrent misses of the same key
+// call the loader only once, each caller waits until its own context is done.
+// If record is not found and there is no loader, then error is returned and
+// list is nil.
+func (a *AtomicCache) GetCtx(ctx context.Context, key []byte) ([]byte, error) {
+	if err := a.checkKey(key); err != nil {
+		return nil, err
 	}
 
-	return nil, 0
+	data, err := a.get(key)
+	if err == ErrNotFound && a.l2 != nil {
+		data, err = a.getL2(key)
+	}
+	if err == ErrNotFound && a.loader == nil {
+		return nil, &NotFoundError{Key: append([]byte(nil), key...)}
+	} else if err != ErrNotFound {
+		return data, err
+	}
+
+	return a.loaderGroup.Do(ctx, string(key), func(ctx context.Context) ([]byte, error) {
+		data, expire, err := a.loader(ctx, key)
+		if err != nil {
+			return nil, err
+		}
+
+		a.SetNoStore(key, data, expire)
+
+		return data, nil
+	})
 }
 
-// getShardsSectionByID returns shards section lookup structure. The function
-// requires the shard section ID on input. If section ID is not valid, nil
-// is returned.
-// This method is not thread safe and additional locks are required.
-func (a *AtomicCache) getShardsSectionByID(sectionID uint8) *ShardsLookup {
-	if sectionID == uint8(SMSH) {
-		return &a.smallShards
-	} else if sectionID == uint8(MDSH) {
-		return &a.mediumShards
-	} else if sectionID == uint8(LGSH) {
-		return &a.largeShards
+// getL2 returns data of record from L2 cache and stores them to cache memory
+// with remaining TTL of L2 record. Errors other than ErrNotFound are logged
+// and ErrNotFound is returned instead.
+func (a *AtomicCache) getL2(key []byte) ([]byte, error) {
+	data, err := a.l2.Get(key)
+	if err != nil {
+		if !errors.Is(err, ErrNotFound) && a.logger != nil {
+			a.logger.Warn("atomiccache: L2 cache get failed", "error", err)
+		}
+		return nil, ErrNotFound
 	}
 
-	return nil
+	expire, err := a.l2.TTL(key)
+	if err != nil {
+		expire = 0
+	}
+	a.SetNoStore(key, data, expire)
+
+	return data, nil
 }
 
-// getRecordSizeByShardSectionID returns maximum record size for specified
-// shard section ID. It returns 0 if there is not known section ID on input.
-// This method is not thread safe and additional locks are required.
-func (a *AtomicCache) getRecordSizeByShardSectionID(sectionID uint8) uint32 {
-	if sectionID == SMSH {
-		return a.RecordSizeSmall
-	} else if sectionID == MDSH {
-		return a.RecordSizeMedium
-	} else if sectionID == LGSH {
-		return a.RecordSizeLarge
+// get returns list of bytes if record is present in cache memory. If record is
+// not found, then error is returned and list is nil.
+func (a *AtomicCache) get(key []byte) ([]byte, error) {
+	start := time.Now()
+	a.RLock()
+	result, val, err := a.getLocked(key)
+	promote := err == nil && a.accessLocked(val)
+	a.RUnlock()
+	a.metrics.RecordGetLatency(time.Since(start))
+	a.traceOp("get", key, err, val)
+
+	if err == nil {
+		a.emitEvent(EventGetHit, key, val.ShardSection, nil)
+		a.recordHit(key, val, promote)
+		return result, nil
 	}
 
-	return 0
+	a.emitEvent(EventGetMiss, key, 0, err)
+
+	a.stats.misses.Add(1)
+	a.metrics.RecordMiss()
+	return nil, err
 }
 
-// getExprTime return expiration time based on duration. If duration is 0, then
-// maximum expiration time is used (48 hours).
-func (a *AtomicCache) getExprTime(expire time.Duration) time.Time {
-	if expire == 0 {
-		return time.Now().Add(48 * time.Hour)
+// GetInto copies data of record to buffer on input and returns number of
+// copied bytes. It does not allocate, so the buffer can be reused across
+// calls. If the buffer is smaller than the record, ErrBufferTooSmall and
+// required size are returned, so the call can be repeated with larger buffer.
+// If record is not found, then error is returned.
+func (a *AtomicCache) GetInto(key []byte, buf []byte) (int, error) {
+	if err := a.checkKey(key); err != nil {
+		return 0, err
+	}
+
+	a.RLock()
+	data, val, err := a.getLocked(key)
+	n := len(data)
+	if err == nil && len(buf) >= n {
+		copy(buf, data)
+	}
+	promote := err == nil && len(buf) >= n && a.accessLocked(val)
+	a.RUnlock()
+
+	if err != nil {
+		a.stats.misses.Add(1)
+		a.metrics.RecordMiss()
+		return 0, err
+	}
+
+	if len(buf) < n {
+		return n, ErrBufferTooSmall
 	}
 
-	return time.Now().Add(expire)
+	a.recordHit(key, val, promote)
+	return n, nil
 }
 
-// collectGarbage provides garbage collect. It goes throught lookup table and
-// checks expiration time. If shard end up empty, then garbage collect release
-// him, but only if there is more than one shard in charge (we always have one
-// active shard).
-func (a *AtomicCache) collectGarbage() {
-	a.Lock()
-	for _, k := range a.lookup.Keys() {
-		iv, _ := a.lookup.Get(k.(string))                      // get record
-		v := iv.(LookupRecord)                                 // convert record from interface to LookupRecord
-		shardSection := a.getShardsSectionByID(v.ShardSection) // get shard section
-		if time.Now().After(v.Expiration) {
-			shardSection.shards[v.ShardIndex].Free(v.RecordIndex)
-			if len(shardSection.shardsActive) > 1 {
-				a.releaseShard(v.ShardSection, v.ShardIndex)
-			}
-			a.lookup.Remove(k)
+// GetRange returns copy of part of record data specified by start (inclusive)
+// and end (exclusive) index. Only the part is copied, so it is cheaper than Get
+// for large records. If range is not within data, ErrOutOfRange is returned.
+// If record is not found, ErrNotFound is returned. If record is expired,
+// ErrExpired is returned.
+func (a *AtomicCache) GetRange(key []byte, start, end int) ([]byte, error) {
+	if err := a.checkKey(key); err != nil {
+		return nil, err
+	}
+
+	var result []byte
+
+	a.RLock()
+	data, val, err := a.getLocked(key)
+	if err == ErrNotFound {
+		if expired, ok := a.lookup.Get(string(key)); ok && !a.isValidRecord(expired, a.clock.Now()) {
+			err = ErrExpired
+		}
+	}
+	if err == nil {
+		if start < 0 || end > len(data) || start > end {
+			err = ErrOutOfRange
+		} else {
+			result = append([]byte{}, data[start:end]...)
 		}
 	}
+	promote := (err == nil || err == ErrOutOfRange) && a.accessLocked(val)
+	a.RUnlock()
+
+	if err != nil && err != ErrOutOfRange {
+		a.stats.misses.Add(1)
+		a.metrics.RecordMiss()
+		return nil, err
+	}
+
+	a.recordHit(key, val, promote)
+	return result, err
+}
+
+// recordHit updates statistics and sliding expiration of record after
+// successful lookup. Access information is updated by accessLocked under the
+// lock of the lookup, record is promoted if it returned true.
+func (a *AtomicCache) recordHit(key []byte, val LookupRecord, promote bool) {
+	if a.slidingExpiration {
+		a.Touch(key, val.OriginalTTL)
+	}
+	if promote {
+		a.promote(key)
+	}
 
-	var localBuffer []BufferItem
-	copy(localBuffer, a.buffer)
-	a.buffer = []BufferItem{}
+	a.stats.hits.Add(1)
+	a.metrics.RecordHit()
+}
+
+// Delete removes record from cache memory. The record memory is freed and if
+// shard ends up empty, it is released (except the last active shard). Negative
+// record of the key is removed too. If record is not found, then error is
+// returned.
+func (a *AtomicCache) Delete(key []byte) error {
+	if err := a.checkKey(key); err != nil {
+		return err
+	}
 
+	if err := a.lockWritable(); err != nil {
+		return err
+	}
+	val, _ := a.lookup.Get(string(key))
+	err := a.removeLocked(key)
 	a.Unlock()
 
-	var bi BufferItem
-	for x := 0; x < len(localBuffer); x++ {
-		bi, localBuffer = localBuffer[0], localBuffer[1:]
-		if err := a.Set(bi.Key, bi.Data, bi.Expire); err != nil {
-			break
+	a.traceOp("delete", key, err, val)
+	a.emitEvent(EventDelete, key, val.ShardSection, err)
+	if err == ErrNotFound {
+		return &NotFoundError{Key: append([]byte(nil), key...)}
+	}
+
+	return err
+}
+
+// removeLocked removes record and negative record of key from cache memory.
+// If record is not found, then error is returned.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) removeLocked(key []byte) error {
+	_, negative := a.negativeKeys[string(key)]
+	delete(a.negativeKeys, string(key))
+
+	if a.counters != nil && a.deleteCounterLocked(string(key)) {
+		a.watchLocked(EventDelete, string(key), nil)
+		return nil
+	}
+
+	val, ok := a.lookup.Get(string(key))
+	if !ok {
+		if negative {
+			return nil
 		}
+		return ErrNotFound
+	}
+
+	a.deleteLocked(string(key), val)
+
+	return nil
+}
+
+// DeleteIf removes record only if function on input returns true for its data.
+// The function gets copy of current data and it is called while the write lock
+// is held, so there is no race between read and delete. It returns true if
+// record was removed. If record is not found or it is expired, then error is
+// returned.
+func (a *AtomicCache) DeleteIf(key []byte, fn func(data []byte) bool) (bool, error) {
+	if err := a.checkKey(key); err != nil {
+		return false, err
+	}
+
+	if err := a.lockWritable(); err != nil {
+		return false, err
+	}
+	data, val, err := a.getLocked(key)
+	if err != nil {
+		a.Unlock()
+		return false, err
+	}
+
+	if !fn(append([]byte{}, data...)) {
+		a.Unlock()
+		return false, nil
+	}
+
+	err = a.removeLocked(key)
+	a.Unlock()
+
+	a.traceOp("delete", key, err, val)
+	a.emitEvent(EventDelete, key, val.ShardSection, err)
+	if err != nil {
+		return false, err
 	}
+
+	return true, nil
+}
+
+// Swap atomically replaces data of record and returns previous data. New
+// expiration time is computed from expire duration. If record is not found or
+// it is expired, then error is returned and nothing is stored.
+func (a *AtomicCache) Swap(key []byte, newData []byte, expire time.Duration) ([]byte, error) {
+	if err := a.checkKey(key); err != nil {
+		return nil, err
+	}
+
+	if len(newData) > int(a.maxItemSize) {
+		return nil, ErrDataLimit
+	}
+
+	if a.valueValidator != nil {
+		if err := a.valueValidator(key, newData); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := a.lockWritable(); err != nil {
+		return nil, err
+	}
+	data, _, err := a.getLocked(key)
+	if err != nil {
+		a.Unlock()
+		return nil, err
+	}
+
+	old := append([]byte{}, data...)
+	collectGarbage, err := a.setLocked(key, newData, expire, a.getExprTime(expire))
+	a.Unlock()
+
+	if collectGarbage {
+		a.startGarbageCollection()
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return old, nil
+}
+
+// GetAndDelete atomically removes record and returns its data. If record is
+// not found or it is expired, then error is returned.
+func (a *AtomicCache) GetAndDelete(key []byte) ([]byte, error) {
+	if err := a.checkKey(key); err != nil {
+		return nil, err
+	}
+
+	if err := a.lockWritable(); err != nil {
+		return nil, err
+	}
+	defer a.Unlock()
+
+	data, val, err := a.getLocked(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data = append([]byte{}, data...)
+	a.deleteLocked(string(key), val)
+
+	return data, nil
+}
+
+// DeletePrefix removes all records which keys start with prefix. It returns
+// number of removed valid records, expired records are removed too, but they
+// are not counted. Watchers and event bus are notified about every removed
+// valid record. If lookup backend keeps keys ordered (BTreeLookup), only
+// matching part of lookup table is visited, otherwise (including the default
+// hash map backend) all keys are visited, so the cost is O(n) of all records.
+// If keys are salted, then ErrKeysSalted is returned.
+func (a *AtomicCache) DeletePrefix(prefix []byte) (int, error) {
+	var keys []string
+	var records []LookupRecord
+	var deleted []int
+
+	if a.keysSalted() {
+		return 0, ErrKeysSalted
+	}
+
+	if err := a.lockWritable(); err != nil {
+		return 0, err
+	}
+
+	if ordered, ok := a.lookup.(OrderedLookupBackend); ok {
+		ordered.Ascend(string(prefix), func(key string, record LookupRecord) bool {
+			if !strings.HasPrefix(key, string(prefix)) {
+				return false
+			}
+			keys = append(keys, key)
+			records = append(records, record)
+			return true
+		})
+	} else {
+		for _, key := range a.lookup.Keys() {
+			if strings.HasPrefix(key, string(prefix)) {
+				record, _ := a.lookup.Get(key)
+				keys = append(keys, key)
+				records = append(records, record)
+			}
+		}
+	}
+
+	for key, counter := range a.counterIndex {
+		if strings.HasPrefix(key, string(prefix)) {
+			keys = append(keys, key)
+			records = append(records, LookupRecord{ShardSection: counterSection, RecordIndex: counter.index, Expiration: counter.expiration, Version: counter.version})
+		}
+	}
+
+	now := a.clock.Now()
+	for i, key := range keys {
+		if !a.isValidRecord(records[i], now) {
+			a.freeLocked(key, records[i])
+			continue
+		}
+		a.deleteLocked(key, records[i])
+		deleted = append(deleted, i)
+	}
+	a.Unlock()
+
+	for _, i := range deleted {
+		a.emitEvent(EventDelete, []byte(keys[i]), records[i].ShardSection, nil)
+	}
+
+	return len(deleted), nil
+}
+
+// deleteLocked removes record same way as freeLocked and notifies watchers of
+// the key about deletion.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) deleteLocked(key string, val LookupRecord) {
+	a.freeLocked(key, val)
+	a.watchLocked(EventDelete, key, nil)
+}
+
+// freeLocked removes record from lookup table and frees its memory. If shard
+// ends up empty, it is released (except the last active shard). Tags of the key
+// are removed from tags index. Watchers are not notified, so it is used when
+// record is replaced, expired or evicted.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) freeLocked(key string, val LookupRecord) {
+	if val.ShardSection == counterSection {
+		a.deleteCounterLocked(key)
+		return
+	}
+
+	shardSection := a.getShardsSectionByID(val.ShardSection)
+	shardSection.shards[val.ShardIndex].Free(val.RecordIndex)
+	if len(shardSection.shardsActive) > 1 {
+		a.releaseShard(val.ShardSection, val.ShardIndex)
+	}
+	a.lookup.Remove(key)
+	a.untagLocked(key)
+}
+
+// putLocked stores lookup record of key. Key and access statistics of record
+// are stored to its slot too, so they can be used by eviction policies.
+// Expiration of counter is stored to counter section.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) putLocked(key string, val LookupRecord) {
+	if val.ShardSection == counterSection {
+		counter := a.counterIndex[key]
+		counter.expiration, counter.ttl = val.Expiration, val.OriginalTTL
+		a.counterIndex[key] = counter
+		return
+	}
+
+	a.lookup.Put(key, val)
+	if record := a.slotLocked(val); record != nil {
+		record.setAccess(key, val.LastAccess, val.AccessCount)
+	}
+}
+
+// slotLocked returns record of slot referenced by lookup record. If shard
+// is not allocated, nil is returned.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) slotLocked(val LookupRecord) *Record {
+	shardSection := a.getShardsSectionByID(val.ShardSection)
+	if shardSection == nil || shardSection.shards[val.ShardIndex] == nil {
+		return nil
+	}
+
+	return shardSection.shards[val.ShardIndex].slots[val.RecordIndex]
+}
+
+// withAccessLocked returns lookup record with current access statistics of
+// its slot (they are not stored to lookup table on every access).
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) withAccessLocked(val LookupRecord) LookupRecord {
+	if record := a.slotLocked(val); record != nil {
+		val.LastAccess, val.AccessCount = record.access()
+	}
+
+	return val
+}
+
+// Exists returns true if record is present in cache memory and it is not
+// expired. Otherwise false is returned.
+func (a *AtomicCache) Exists(key []byte) bool {
+	if a.checkKey(key) != nil {
+		return false
+	}
+
+	var result = false
+
+	a.RLock()
+	if _, ok := a.getCounterLocked(string(key)); ok {
+		result = true
+	} else if val, ok := a.lookup.Get(string(key)); ok {
+		result = a.isValidRecord(val, a.clock.Now())
+	}
+	a.RUnlock()
+
+	return result
+}
+
+// Expire sets new expiration time of record. The expiration is computed from
+// current time same way as in Set. If record is not found or it is already
+// expired, then error is returned.
+func (a *AtomicCache) Expire(key []byte, expire time.Duration) error {
+	if err := a.checkKey(key); err != nil {
+		return err
+	}
+
+	if err := a.lockWritable(); err != nil {
+		return err
+	}
+	defer a.Unlock()
+
+	return a.expireLocked(key, expire)
+}
+
+// IncrExpire adds delta to current expiration time of record (not to current
+// time as Expire does) and returns new remaining TTL. It is useful for lock
+// extension, where lifetime is extended by delta regardless of how much time
+// is left. Negative delta shortens lifetime, if the record expires by it, 0 is
+// returned. Record without expiration is not modified and NoExpiry is
+// returned. If record is not found or it is already expired, then error is
+// returned.
+func (a *AtomicCache) IncrExpire(key []byte, delta time.Duration) (time.Duration, error) {
+	if err := a.checkKey(key); err != nil {
+		return 0, err
+	}
+
+	if err := a.lockWritable(); err != nil {
+		return 0, err
+	}
+	defer a.Unlock()
+
+	now := a.clock.Now()
+	_, val, err := a.getLocked(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if val.Expiration.IsZero() {
+		return NoExpiry, nil
+	}
+
+	val.Expiration = val.Expiration.Add(delta)
+	a.putLocked(string(key), val)
+
+	if ttl := val.Expiration.Sub(now); ttl > 0 {
+		return ttl, nil
+	}
+
+	return 0, nil
+}
+
+// expireLocked sets new expiration time of record. If record is not found or
+// it is already expired, then error is returned.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) expireLocked(key []byte, expire time.Duration) error {
+	_, val, err := a.getLocked(key)
+	if err != nil {
+		return err
+	}
+
+	val.Expiration = a.getExprTime(expire)
+	a.putLocked(string(key), val)
+
+	return nil
+}
+
+// ExpireIf sets new expiration time of all valid records for which function
+// on input returns true. The function gets key and lookup record and it is
+// called while the write lock is held, so it must not call the cache. It
+// returns number of updated records. If keys are salted, only counters are
+// visited, because original keys of lookup table are not known.
+func (a *AtomicCache) ExpireIf(fn func(key []byte, meta LookupRecord) bool, newTTL time.Duration) int {
+	var updated int
+
+	if err := a.lockWritable(); err != nil {
+		return 0
+	}
+	defer a.Unlock()
+
+	var keys []string
+	if !a.keysSalted() {
+		keys = a.lookup.Keys()
+	}
+	for key := range a.counterIndex {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		_, val, err := a.getLocked([]byte(key))
+		if err != nil || !fn([]byte(key), val) {
+			continue
+		}
+
+		val.Expiration = a.getExprTime(newTTL)
+		a.putLocked(key, val)
+		updated++
+	}
+
+	return updated
+}
+
+// Touch extends lifetime of record without reading its data. Expiration time
+// is computed from current time same way as in Set and last access time of
+// record is updated. If record is not found or it is already expired, then
+// error is returned.
+func (a *AtomicCache) Touch(key []byte, expire time.Duration) error {
+	if err := a.checkKey(key); err != nil {
+		return err
+	}
+
+	if err := a.lockWritable(); err != nil {
+		return err
+	}
+	defer a.Unlock()
+
+	_, val, err := a.getLocked(key)
+	if err != nil {
+		return err
+	}
+
+	val.Expiration = a.getExprTime(expire)
+	val.LastAccess = a.clock.Now()
+	a.putLocked(string(key), val)
+
+	return nil
+}
+
+// Scan returns list of all valid keys which starts with prefix. Keys are
+// returned in lexicographical order. Empty prefix matches all keys. If keys
+// are salted, only keys of counters are returned, because original keys of
+// lookup table are not known.
+func (a *AtomicCache) Scan(prefix string) [][]byte {
+	var result [][]byte
+	var keys []string
+
+	now := a.clock.Now()
+
+	a.RLock()
+	if !a.keysSalted() {
+		keys = a.lookup.Keys()
+	}
+	for _, key := range keys {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if val, ok := a.lookup.Get(key); ok && a.isValidRecord(val, now) {
+			result = append(result, []byte(key))
+		}
+	}
+	for key := range a.counterIndex {
+		if _, ok := a.getCounterLocked(key); ok && strings.HasPrefix(key, prefix) {
+			result = append(result, []byte(key))
+		}
+	}
+	a.RUnlock()
+
+	sort.Slice(result, func(i, j int) bool {
+		return bytes.Compare(result[i], result[j]) < 0
+	})
+
+	return result
+}
+
+// GetKeysExpiring returns list of valid keys which expire within specified
+// duration. Already expired keys are not included. It can be used for
+// proactive refresh of records before they expire. If keys are salted, nil is
+// returned, because original keys of lookup table are not known.
+func (a *AtomicCache) GetKeysExpiring(within time.Duration) [][]byte {
+	var result [][]byte
+	var keys []string
+
+	now := a.clock.Now()
+	deadline := now.Add(within)
+
+	a.RLock()
+	if !a.keysSalted() {
+		keys = a.lookup.Keys()
+	}
+	for _, key := range keys {
+		if val, ok := a.lookup.Get(key); ok && val.Version == a.version.Load() && now.Before(val.Expiration) && !val.Expiration.After(deadline) {
+			result = append(result, []byte(key))
+		}
+	}
+	a.RUnlock()
+
+	return result
+}
+
+// RandomKey returns random valid key from cache memory. If randomly selected
+// key is expired, another one is selected (up to 10 attempts). If cache is
+// empty or no valid key is found, then error is returned. If keys are salted,
+// then ErrKeysSalted is returned.
+func (a *AtomicCache) RandomKey() ([]byte, error) {
+	if a.keysSalted() {
+		return nil, ErrKeysSalted
+	}
+
+	a.RLock()
+	defer a.RUnlock()
+
+	keys := a.lookup.Keys()
+	if len(keys) == 0 {
+		return nil, ErrNotFound
+	}
+
+	now := a.clock.Now()
+	for i := 0; i < 10; i++ {
+		key := keys[rand.Intn(len(keys))]
+		if val, ok := a.lookup.Get(key); ok && a.isValidRecord(val, now) {
+			return []byte(key), nil
+		}
+	}
+
+	return nil, ErrNotFound
+}
+
+// TTL returns remaining time to live of record. If record never expires, then
+// NoExpiry is returned. If record is not found or it is expired, then error is
+// returned.
+func (a *AtomicCache) TTL(key []byte) (time.Duration, error) {
+	if err := a.checkKey(key); err != nil {
+		return 0, err
+	}
+
+	a.RLock()
+	val, ok := a.lookup.Get(string(key))
+	if counter, isCounter := a.getCounterLocked(string(key)); isCounter {
+		val, ok = LookupRecord{Expiration: counter.expiration, Version: counter.version}, true
+	}
+	a.RUnlock()
+
+	if !ok || val.Version != a.version.Load() {
+		return 0, ErrNotFound
+	}
+
+	if val.Expiration.IsZero() {
+		return NoExpiry, nil
+	}
+
+	ttl := val.Expiration.Sub(a.clock.Now())
+	if ttl <= 0 {
+		return 0, ErrNotFound
+	}
+
+	return ttl, nil
+}
+
+// GetOrSet returns data of record if it is present in cache memory. If record
+// is not found, then function on input is called and its result is stored with
+// specified expiration and returned.
+func (a *AtomicCache) GetOrSet(key []byte, fn func() ([]byte, error), expire time.Duration) ([]byte, error) {
+	if err := a.checkKey(key); err != nil {
+		return nil, err
+	}
+
+	if data, err := a.Get(key); err == nil {
+		return data, nil
+	}
+
+	data, err := fn()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.Set(key, data, expire); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// GetOrSetCtx returns data of record same way as GetOrSet, but function on
+// input gets context, so it can be cancelled. Concurrent calls with the same
+// key are deduplicated, only the first one calls the function and others get
+// its result. If context is done when the function returns, context error is
+// returned and nothing is stored. The error is not shared, the function is
+// called again for other callers, which wait until their own context is done.
+func (a *AtomicCache) GetOrSetCtx(ctx context.Context, key []byte, fn func(context.Context) ([]byte, error), expire time.Duration) ([]byte, error) {
+	if err := a.checkKey(key); err != nil {
+		return nil, err
+	}
+
+	if data, err := a.Get(key); err == nil {
+		return data, nil
+	}
+
+	return a.getOrSetGroup.Do(ctx, string(key), func(ctx context.Context) ([]byte, error) {
+		data, err := fn(ctx)
+		if ctxErr := ctx.Err(); ctxErr != nil {
+			return nil, ctxErr
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if err := a.SetCtx(ctx, key, data, expire); err != nil {
+			return nil, err
+		}
+
+		return data, nil
+	})
+}
+
+// GetXFetch returns data of record and uses probabilistic early expiration
+// (XFetch algorithm) to prevent cache stampede. Data are recomputed by function
+// on input before the record expires, with probability which increases as the
+// expiration approaches. The probability is driven by beta (1.0 is a good
+// default, bigger value means earlier recomputation) and duration returned by
+// recomputeTime. If record is not found, data are always recomputed. The
+// recomputed data are stored with specified expiration.
+func (a *AtomicCache) GetXFetch(key []byte, beta float64, recomputeTime func() time.Duration, fn func() ([]byte, error), expire time.Duration) ([]byte, error) {
+	if err := a.checkKey(key); err != nil {
+		return nil, err
+	}
+
+	if data, err := a.Get(key); err == nil {
+		if ttl, err := a.TTL(key); err == nil {
+			if ttl == NoExpiry {
+				return data, nil
+			}
+
+			delta := float64(recomputeTime())
+			if -delta*beta*math.Log(rand.Float64()) < float64(ttl) {
+				return data, nil
+			}
+		}
+	}
+
+	data, err := fn()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.Set(key, data, expire); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Flush removes all records (including counters, negative records and tags
+// index) from cache memory. All shards are released and the cache ends up in
+// the same state as after initialization.
+func (a *AtomicCache) Flush() error {
+	if err := a.lockWritable(); err != nil {
+		return err
+	}
+	a.flushLocked()
+	a.Unlock()
+
+	return nil
+}
+
+// flushLocked removes all records from cache memory. Watchers of removed
+// records are notified about deletion.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) flushLocked() {
+	for _, key := range a.watchedKeys() {
+		if _, _, err := a.getLocked([]byte(key)); err == nil {
+			a.watchLocked(EventDelete, key, nil)
+		}
+	}
+
+	a.lookup.Clear()
+	for i := range a.sections {
+		a.initShardsSection(&a.sections[i], a.sections[i].maxShards, a.sections[i].recordSize)
+	}
+	a.buffer.reset()
+	a.negativeKeys = make(map[string]time.Time)
+	if a.counters != nil {
+		a.resetCountersLocked()
+	}
+	a.tags = make(map[string]map[string]struct{})
+	a.keyTags = make(map[string]map[string]struct{})
+}
+
+// Close removes all records from cache memory (even in read-only mode) and
+// stops background prefetch. Cache should not be used after it is closed.
+func (a *AtomicCache) Close() error {
+	a.prefetchStopOnce.Do(func() { close(a.prefetchStop) })
+	a.Lock()
+	a.flushLocked()
+	a.Unlock()
+
+	return nil
+}
+
+// SetReadOnly enables or disables read-only mode at runtime. In read-only
+// mode all methods which modify records (e.g. Set, Delete, Update, Expire,
+// Incr, LockKey, Flush or Pipeline operations) return ErrReadOnly immediately
+// without any lock. Reading methods and garbage collection are not affected.
+// The mode is changed under write lock, so no modification is in progress
+// when it returns.
+func (a *AtomicCache) SetReadOnly(enabled bool) {
+	a.Lock()
+	a.readOnly.Store(enabled)
+	a.Unlock()
+}
+
+// checkWritable returns ErrReadOnly if cache is in read-only mode.
+func (a *AtomicCache) checkWritable() error {
+	if a.readOnly.Load() {
+		return ErrReadOnly
+	}
+
+	return nil
+}
+
+// lockWritable acquires write lock for modification of records. Every method
+// which modifies records acquires the lock by it, so read-only mode is checked
+// at one place. The mode is checked again under the lock, because it may be
+// enabled while the lock is awaited. If cache is in read-only mode,
+// ErrReadOnly is returned and the lock is not held.
+func (a *AtomicCache) lockWritable() error {
+	if err := a.checkWritable(); err != nil {
+		return err
+	}
+
+	a.Lock()
+	if err := a.checkWritable(); err != nil {
+		a.Unlock()
+		return err
+	}
+
+	return nil
+}
+
+// rlockWritable acquires read lock for modification of records which are
+// updated atomically under read lock (e.g. counters). Read-only mode is
+// checked same way as in lockWritable.
+func (a *AtomicCache) rlockWritable() error {
+	if err := a.checkWritable(); err != nil {
+		return err
+	}
+
+	a.RLock()
+	if err := a.checkWritable(); err != nil {
+		a.RUnlock()
+		return err
+	}
+
+	return nil
+}
+
+// Type returns name of shard section ("small", "medium" or "large") which
+// stores the record. If custom tiers are used (other than three), the name is
+// "tier-N", where N is section ID. Records of counter section are of type
+// "counter". If record is not found or it is expired, then error is returned.
+func (a *AtomicCache) Type(key []byte) (string, error) {
+	if err := a.checkKey(key); err != nil {
+		return "", err
+	}
+
+	a.RLock()
+	_, val, err := a.getLocked(key)
+	a.RUnlock()
+
+	if err != nil {
+		return "", err
+	}
+
+	section := val.ShardSection
+	if section == counterSection {
+		return "counter", nil
+	}
+
+	if section == a.overflowSection {
+		return "overflow", nil
+	}
+
+	if a.overflowSection != 0 && len(a.sections) != 4 || a.overflowSection == 0 && len(a.sections) != 3 {
+		return fmt.Sprintf("tier-%d", section), nil
+	}
+
+	switch section {
+	case SMSH:
+		return "small", nil
+	case MDSH:
+		return "medium", nil
+	}
+
+	return "large", nil
+}
+
+// KeyHash returns key used in lookup table for key on input. If key salt is
+// set, it is hex encoded HMAC-SHA256 of the key, otherwise the key is returned
+// unchanged.
+func (a *AtomicCache) KeyHash(key []byte) string {
+	if salted, ok := a.lookup.(*SaltedLookup); ok {
+		return salted.Hash(string(key))
+	}
+
+	return string(key)
+}
+
+// keysSalted returns true if lookup table stores only digests of keys (see
+// Options.KeySalt), so its keys cannot be listed.
+func (a *AtomicCache) keysSalted() bool {
+	_, ok := a.lookup.(*SaltedLookup)
+	return ok
+}
+
+// lookupKeysLocked returns original keys of all lookup records for internal
+// maintenance (e.g. garbage collection). If keys are salted, lookup table
+// stores only digests, so keys are taken from slots of records, where they are
+// kept for eviction policies. Keys are never listed to the caller this way.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) lookupKeysLocked() []string {
+	salted, ok := a.lookup.(*SaltedLookup)
+	if !ok {
+		return a.lookup.Keys()
+	}
+
+	keys := make([]string, 0, salted.Size())
+	for _, hash := range salted.backend.Keys() {
+		val, _ := salted.backend.Get(hash)
+		if record := a.slotLocked(val); record != nil && record.key != "" {
+			keys = append(keys, record.key)
+		}
+	}
+
+	return keys
+}
+
+// GetMeta returns copy of lookup record for specified key. It contains shard
+// section, shard index, record index and expiration time. Shard section of
+// counter is 0 and record index is index in counter section. If record is not
+// found, ErrNotFound is returned. If record is expired, ErrExpired is returned.
+func (a *AtomicCache) GetMeta(key []byte) (LookupRecord, error) {
+	if err := a.checkKey(key); err != nil {
+		return LookupRecord{}, err
+	}
+
+	a.RLock()
+	_, val, err := a.getLocked(key)
+	if err == ErrNotFound {
+		if expired, ok := a.lookup.Get(string(key)); ok && !a.isValidRecord(expired, a.clock.Now()) {
+			err = ErrExpired
+		}
+	}
+	a.RUnlock()
+
+	if err != nil {
+		return LookupRecord{}, err
+	}
+
+	return val, nil
+}
+
+// getLocked returns data and lookup record of key. If record is not found or
+// it is expired, ErrNotFound is returned. If checksums are enabled and record
+// is corrupted, ErrChecksumMismatch is returned.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) getLocked(key []byte) ([]byte, LookupRecord, error) {
+	if counter, ok := a.getCounterLocked(string(key)); ok {
+		data := strconv.AppendInt(nil, a.counters[counter.index].Load(), 10)
+		return data, LookupRecord{ShardSection: counterSection, RecordIndex: counter.index, Expiration: counter.expiration, OriginalTTL: counter.ttl, Version: counter.version}, nil
+	}
+
+	if val, ok := a.lookup.Get(string(key)); ok {
+		shardSection := a.getShardsSectionByID(val.ShardSection)
+
+		if shardSection.shards[val.ShardIndex] != nil && a.isValidRecord(val, a.clock.Now()) {
+			data, err := shardSection.shards[val.ShardIndex].GetVerified(val.RecordIndex)
+			if err != nil {
+				return nil, LookupRecord{}, err
+			}
+			if a.pool != nil {
+				// Shard memory can be passed to another cache after release,
+				// so data must not alias it.
+				data = append([]byte{}, data...)
+			}
+			return data, a.withAccessLocked(val), nil
+		}
+	}
+
+	return nil, LookupRecord{}, ErrNotFound
+}
+
+// releaseShard release shard if there is no record in memory. It returns true
+// if shard was released. The function requires the shard section ID and
+// shard ID on input.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) releaseShard(shardSectionID uint8, shard uint32) bool {
+	var shardSection *ShardsLookup
+
+	if shardSection = a.getShardsSectionByID(shardSectionID); shardSection == nil {
+		return false
+	}
+
+	if shardSection.shards[shard].IsEmpty() == true {
+		if !a.eagerAllocation {
+			a.freeShard(shardSection.shards[shard])
+			shardSection.shards[shard] = nil
+		}
+
+		shardSection.shardsAvail = append(shardSection.shardsAvail, shard)
+		for k, v := range shardSection.shardsActive {
+			if v == shard {
+				shardSection.shardsActive = append(shardSection.shardsActive[:k], shardSection.shardsActive[k+1:]...)
+				break
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// getShard return index of shard which have some available space for new
+// record. If there is no shard with available space, then false is returned as
+// a second value. The function requires the shard section ID on input.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) getShard(shardSectionID uint8) (uint32, bool) {
+	var shardSection *ShardsLookup
+
+	if shardSection = a.getShardsSectionByID(shardSectionID); shardSection == nil {
+		return 0, false
+	}
+
+	for _, shardIndex := range shardSection.shardsActive {
+		if shardSection.shards[shardIndex].GetSlotsAvail() != 0 {
+			return shardIndex, true
+		}
+	}
+
+	return 0, false
+}
+
+// getEmptyShard return index of shard that can be used for new shard
+// allocation. If there is no left index, then false is returned as a second
+// value. The function requires the shard section ID on input.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) getEmptyShard(shardSectionID uint8) (uint32, bool) {
+	var shardSection *ShardsLookup
+
+	if shardSection = a.getShardsSectionByID(shardSectionID); shardSection == nil {
+		return 0, false
+	}
+
+	if len(shardSection.shardsAvail) == 0 {
+		return 0, false
+	}
+
+	var shardIndex uint32
+	shardIndex, shardSection.shardsAvail = shardSection.shardsAvail[0], shardSection.shardsAvail[1:]
+	shardSection.shardsActive = append(shardSection.shardsActive, shardIndex)
+
+	return shardIndex, true
+}
+
+// getShardsSectionBySize returns shards section lookup structure and section
+// identifier as a second value. The function requires the data size value on
+// input. If data are bigger than allowed value, then nil and 0 is returned.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) getShardsSectionBySize(dataSize int) (*ShardsLookup, uint8) {
+	i := sort.Search(len(a.sections), func(i int) bool {
+		return dataSize <= int(a.sections[i].recordSize)
+	})
+
+	if i == len(a.sections) {
+		return nil, 0
+	}
+
+	return &a.sections[i], uint8(i + 1)
+}
+
+// getShardsSectionByID returns shards section lookup structure. The function
+// requires the shard section ID on input. If section ID is not valid, nil
+// is returned.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) getShardsSectionByID(sectionID uint8) *ShardsLookup {
+	if sectionID == 0 || int(sectionID) > len(a.sections) {
+		return nil
+	}
+
+	return &a.sections[sectionID-1]
+}
+
+// getRecordSizeByShardSectionID returns maximum record size for specified
+// shard section ID. It returns 0 if there is not known section ID on input.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) getRecordSizeByShardSectionID(sectionID uint8) uint32 {
+	if shardSection := a.getShardsSectionByID(sectionID); shardSection != nil {
+		return shardSection.recordSize
+	}
+
+	return 0
+}
+
+// checkKey returns error if key length is out of allowed range.
+func (a *AtomicCache) checkKey(key []byte) error {
+	if len(key) < a.minKeyLength || len(key) == 0 {
+		return ErrKeyTooShort
+	}
+	if a.maxKeyLength > 0 && len(key) > a.maxKeyLength {
+		return ErrKeyTooLong
+	}
+
+	return nil
+}
+
+// getExprTime return expiration time based on duration. If duration is 0, then
+// default expiration time is used (48 hours if not set). If duration is
+// NoExpiry, then zero time is returned, which means record never expires. If
+// expiration jitter is set, then random duration up to jitter is added
+// (math/rand, not cryptographically secure).
+func (a *AtomicCache) getExprTime(expire time.Duration) time.Time {
+	if expire == NoExpiry {
+		return time.Time{}
+	}
+
+	if expire == 0 {
+		expire = a.defaultTTL
+	}
+
+	if a.expirationJitter > 0 {
+		expire += time.Duration(rand.Int63n(int64(a.expirationJitter)))
+	}
+
+	return a.clock.Now().Add(expire)
+}
+
+// isValid returns true if expiration time is zero (record never expires) or it
+// is after specified time.
+func isValid(expiration, now time.Time) bool {
+	return expiration.IsZero() || now.Before(expiration)
+}
+
+// isValidRecord returns true if record is of current cache version and it is
+// not expired at specified time.
+func (a *AtomicCache) isValidRecord(val LookupRecord, now time.Time) bool {
+	return val.Version == a.version.Load() && isValid(val.Expiration, now)
+}
+
+// Bump increments cache version and returns the new one. All records stored
+// before are treated as missing immediately, but they are removed lazily by
+// garbage collector (or replaced by Set). Unlike Flush, it does not block the
+// cache memory. In read-only mode the version is not changed and the current
+// one is returned.
+func (a *AtomicCache) Bump() uint32 {
+	if a.checkWritable() != nil {
+		return a.version.Load()
+	}
+
+	return a.version.Add(1)
+}
+
+// collectGarbage provides garbage collect. It goes throught lookup table and
+// checks expiration time. If shard end up empty, then garbage collect release
+// him, but only if there is more than one shard in charge (we always have one
+// active shard).
+func (a *AtomicCache) collectGarbage() {
+	var evicted int
+	start := time.Now()
+
+	a.Lock()
+	scanned := a.lookup.Size() + len(a.counterIndex)
+	evicted = a.collectExpiredLocked()
+	if a.adaptiveGC {
+		a.gc.adapt(evicted, scanned)
+	}
+	a.stats.recordGcDuration(time.Since(start))
+	a.recordStatsCheckpoint()
+	a.metrics.RecordGCDuration(time.Since(start))
+	a.traceGc(evicted, time.Since(start))
+
+	if a.logger != nil {
+		a.logger.Info("atomiccache: garbage collection finished", "keys_evicted", evicted, "duration_ms", time.Since(start).Milliseconds())
+	}
+
+	// Replay buffered requests while the lock is still held. Requests which
+	// still do not fit end up in buffer again.
+	localBuffer := a.buffer.drain()
+	for _, bi := range localBuffer {
+		if _, err := a.setLocked(bi.Key, bi.Data, bi.Expire, a.getExprTime(bi.Expire)); err != nil {
+			break
+		}
+	}
+
+	a.Unlock()
+}
+
+// notifyExpiration sends copy of expired key to expiration channel (if it is
+// set). The send does not block, so the key is dropped if channel is full.
+func (a *AtomicCache) notifyExpiration(key string) {
+	if a.expirationNotify == nil {
+		return
+	}
+
+	select {
+	case a.expirationNotify <- []byte(key):
+	default:
+	}
+}
+
+// TrimExpired removes all expired records synchronously and returns number of
+// removed records. Unlike garbage collector, it does not process buffer of
+// unattended set requests.
+func (a *AtomicCache) TrimExpired() int {
+	a.Lock()
+	evicted := a.collectExpiredLocked()
+	a.Unlock()
+
+	return evicted
+}
+
+// collectExpiredLocked removes all expired records and negative records. It
+// returns number of removed records.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) collectExpiredLocked() int {
+	var evicted int
+
+	for _, k := range a.lookupKeysLocked() {
+		v, _ := a.lookup.Get(k) // get record
+		if !a.isValidRecord(v, a.clock.Now()) {
+			a.freeLocked(k, v)
+			a.emitEvent(EventEvict, []byte(k), v.ShardSection, nil)
+			if !isValid(v.Expiration, a.clock.Now()) {
+				a.notifyExpiration(k)
+				a.watchLocked(EventExpire, k, nil)
+			}
+			evicted++
+		}
+	}
+
+	for k, counter := range a.counterIndex {
+		if !isValid(counter.expiration, a.clock.Now()) || counter.version != a.version.Load() {
+			a.deleteCounterLocked(k)
+			a.emitEvent(EventEvict, []byte(k), counterSection, nil)
+			if !isValid(counter.expiration, a.clock.Now()) {
+				a.notifyExpiration(k)
+				a.watchLocked(EventExpire, k, nil)
+			}
+			evicted++
+		}
+	}
+
+	for k, expiration := range a.negativeKeys {
+		if !isValid(expiration, a.clock.Now()) {
+			delete(a.negativeKeys, k)
+		}
+	}
+
+	a.stats.evictions.Add(uint64(evicted))
+	for i := 0; i < evicted; i++ {
+		a.metrics.RecordEviction()
+	}
+
+	return evicted
 }
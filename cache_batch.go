@@ -0,0 +1,174 @@
+package atomiccache
+
+import (
+	"time"
+)
+
+// CacheItem is one record of batch operation. Zero expiration means default
+// expiration of the batch.
+type CacheItem struct {
+	Key    []byte
+	Data   []byte
+	Expire time.Duration
+}
+
+// SetEach stores all items under one write lock. Items with zero expiration
+// are stored with defaultExpire. It returns error of every item in the same
+// order as items (nil if item was stored). Unlike Set, write-through store is
+// not used.
+func (a *AtomicCache) SetEach(items []CacheItem, defaultExpire time.Duration) []error {
+	collectGarbage := false
+	errs := make([]error, len(items))
+
+	if err := a.lockWritable(); err != nil {
+		for i := range errs {
+			errs[i] = err
+		}
+		return errs
+	}
+	for i, item := range items {
+		if err := a.checkKey(item.Key); err != nil {
+			errs[i] = err
+			continue
+		}
+		if len(item.Data) > int(a.maxItemSize) {
+			errs[i] = ErrDataLimit
+			continue
+		}
+		if a.valueValidator != nil {
+			if err := a.valueValidator(item.Key, item.Data); err != nil {
+				errs[i] = err
+				continue
+			}
+		}
+
+		expire := item.Expire
+		if expire == 0 {
+			expire = defaultExpire
+		}
+
+		gc, err := a.setLocked(item.Key, item.Data, expire, a.getExprTime(expire))
+		collectGarbage = collectGarbage || gc
+		errs[i] = err
+	}
+	a.Unlock()
+
+	if collectGarbage {
+		a.startGarbageCollection()
+	}
+
+	return errs
+}
+
+// FailedItem is item which was not stored by SetManyReport with its error.
+type FailedItem struct {
+	Key []byte
+	Err error
+}
+
+// SetManyResult is report of SetManyReport. It contains keys of stored items
+// and failed items in the same order as input items.
+type SetManyResult struct {
+	Stored []string
+	Failed []FailedItem
+}
+
+// SetManyReport stores all items same way as SetEach (items with zero
+// expiration are stored with default TTL). It does not stop on failure, so
+// as many items as possible are stored, and returns report of stored and
+// failed items, which can be used for retry or logging.
+func (a *AtomicCache) SetManyReport(items []CacheItem) SetManyResult {
+	var result SetManyResult
+
+	for i, err := range a.SetEach(items, 0) {
+		if err != nil {
+			result.Failed = append(result.Failed, FailedItem{Key: items[i].Key, Err: err})
+		} else {
+			result.Stored = append(result.Stored, string(items[i].Key))
+		}
+	}
+
+	return result
+}
+
+// MDelete removes records of all keys under one write lock. Memory of records
+// is freed and emptied shards are released same way as in Delete. It returns
+// number of removed records, missing keys are skipped. If some key is not
+// valid, then nothing is removed and error is returned.
+func (a *AtomicCache) MDelete(keys [][]byte) (int, error) {
+	var deleted int
+
+	for _, key := range keys {
+		if err := a.checkKey(key); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := a.lockWritable(); err != nil {
+		return 0, err
+	}
+	for _, key := range keys {
+		delete(a.negativeKeys, string(key))
+
+		if a.counters != nil && a.deleteCounterLocked(string(key)) {
+			a.watchLocked(EventDelete, string(key), nil)
+			deleted++
+		} else if val, ok := a.lookup.Get(string(key)); ok {
+			a.deleteLocked(string(key), val)
+			deleted++
+		}
+	}
+	a.Unlock()
+
+	return deleted, nil
+}
+
+// BatchResult is result of one key of GetBatch. It contains either data or
+// error of the key.
+type BatchResult struct {
+	Key  []byte
+	Data []byte
+	Err  error
+}
+
+// GetBatch returns records of all keys under one read lock. Results are in the
+// same order as keys and every result contains either data or error of the
+// key (ErrNotFound if record is missing, ErrExpired if it is expired). Loader
+// and L2 cache are not used. Error is returned only if the whole batch fails,
+// individual errors are reported in results.
+func (a *AtomicCache) GetBatch(keys [][]byte) ([]BatchResult, error) {
+	results := make([]BatchResult, len(keys))
+	records := make([]LookupRecord, len(keys))
+	promote := make([]bool, len(keys))
+
+	a.RLock()
+	now := a.clock.Now()
+	for i, key := range keys {
+		results[i].Key = key
+		if err := a.checkKey(key); err != nil {
+			results[i].Err = err
+			continue
+		}
+
+		results[i].Data, records[i], results[i].Err = a.getLocked(key)
+		if results[i].Err == nil {
+			promote[i] = a.accessLocked(records[i])
+		} else if results[i].Err == ErrNotFound {
+			if val, ok := a.lookup.Get(string(key)); ok && !a.isValidRecord(val, now) {
+				results[i].Err = ErrExpired
+			}
+		}
+	}
+	a.RUnlock()
+
+	for i := range results {
+		if results[i].Err == nil {
+			a.recordHit(keys[i], records[i], promote[i])
+		} else {
+			a.stats.misses.Add(1)
+			a.metrics.RecordMiss()
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,114 @@
+package atomiccache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCacheSetEach(t *testing.T) {
+	cache := New()
+
+	errs := cache.SetEach([]CacheItem{
+		{Key: []byte("key1"), Data: []byte("data1"), Expire: time.Minute},
+		{Key: []byte("key2"), Data: []byte("data2")},
+		{Key: []byte{}, Data: []byte("data3")},
+		{Key: []byte("key4"), Data: make([]byte, cache.RecordSizeLarge+1)},
+	}, time.Hour)
+
+	expected := []error{nil, nil, ErrKeyTooShort, ErrDataLimit}
+	if !reflect.DeepEqual(errs, expected) {
+		t.Errorf("%v != %v", errs, expected)
+	}
+
+	if ttl, _ := cache.TTL([]byte("key1")); ttl > time.Minute {
+		t.Errorf("TTL %v is greater than %v", ttl, time.Minute)
+	}
+	if ttl, _ := cache.TTL([]byte("key2")); ttl <= time.Minute {
+		t.Errorf("TTL %v is not default expiration", ttl)
+	}
+	if value, err := cache.Get([]byte("key2")); err != nil || !reflect.DeepEqual(value, []byte("data2")) {
+		t.Errorf("%v != %v", value, []byte("data2"))
+	}
+}
+
+func TestCacheSetManyReport(t *testing.T) {
+	cache := New(OptionMaxRecords(2), OptionMaxShardsSmall(1), OptionMaxBufferSize(0))
+
+	result := cache.SetManyReport([]CacheItem{
+		{Key: []byte("key1"), Data: []byte("data")},
+		{Key: []byte("key2"), Data: []byte("data"), Expire: time.Minute},
+		{Key: []byte("key3"), Data: []byte("data")},
+		{Key: []byte("key4"), Data: make([]byte, 9000)},
+	})
+
+	if !reflect.DeepEqual(result.Stored, []string{"key1", "key2"}) {
+		t.Errorf("%v != %v", result.Stored, []string{"key1", "key2"})
+	}
+	if len(result.Failed) != 2 {
+		t.Fatalf("%v != %v", len(result.Failed), 2)
+	}
+	if string(result.Failed[0].Key) != "key3" || result.Failed[0].Err != ErrFullMemory {
+		t.Errorf("%s, %v != key3, %v", result.Failed[0].Key, result.Failed[0].Err, ErrFullMemory)
+	}
+	if string(result.Failed[1].Key) != "key4" || result.Failed[1].Err != ErrDataLimit {
+		t.Errorf("%s, %v != key4, %v", result.Failed[1].Key, result.Failed[1].Err, ErrDataLimit)
+	}
+}
+
+func TestCacheMDelete(t *testing.T) {
+	cache := New(OptionMaxRecords(2), OptionMaxShardsSmall(2))
+	for i := 0; i < 4; i++ {
+		cache.Set([]byte{'k', byte(i)}, []byte("data"), 0)
+	}
+
+	if deleted, err := cache.MDelete([][]byte{{'k', 0}, {'k', 1}, {'k', 2}, []byte("missing")}); err != nil || deleted != 3 {
+		t.Errorf("%v != %v", deleted, 3)
+	}
+	if !cache.Exists([]byte{'k', 3}) || cache.Exists([]byte{'k', 0}) {
+		t.Errorf("Unexpected records after MDelete")
+	}
+	if active := len(cache.sections[SMSH-1].shardsActive); active != 1 {
+		t.Errorf("%v != %v", active, 1)
+	}
+
+	if _, err := cache.MDelete([][]byte{{'k', 3}, {}}); err != ErrKeyTooShort {
+		t.Errorf("Expecting error 'ErrKeyTooShort'")
+	}
+	if !cache.Exists([]byte{'k', 3}) {
+		t.Errorf("Record is removed despite invalid key")
+	}
+}
+
+func TestCacheGetBatch(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key1"), []byte("data1"), 0)
+	cache.Set([]byte("key2"), []byte{}, 0)
+	cache.Set([]byte("expired"), []byte("data"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	keys := [][]byte{[]byte("key1"), []byte("missing"), []byte("expired"), []byte("key2"), []byte("")}
+	results, err := cache.GetBatch(keys)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if len(results) != len(keys) {
+		t.Fatalf("%v != %v", len(results), len(keys))
+	}
+
+	for i, want := range []BatchResult{
+		{Key: keys[0], Data: []byte("data1")},
+		{Key: keys[1], Err: ErrNotFound},
+		{Key: keys[2], Err: ErrExpired},
+		{Key: keys[3], Data: []byte{}},
+		{Key: keys[4], Err: ErrKeyTooShort},
+	} {
+		if !reflect.DeepEqual(results[i], want) {
+			t.Errorf("%v != %v", results[i], want)
+		}
+	}
+
+	if stats := cache.Stats(); stats.Hits != 2 || stats.Misses != 3 {
+		t.Errorf("%v, %v != 2, 3", stats.Hits, stats.Misses)
+	}
+}
@@ -0,0 +1,65 @@
+package atomiccache
+
+// liveRecord is copy of valid record with its lookup record.
+type liveRecord struct {
+	key  string
+	data []byte
+	val  LookupRecord
+}
+
+// liveRecordsLocked returns copies of all valid records (including counters).
+// Keys must not be salted.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) liveRecordsLocked() []liveRecord {
+	var records []liveRecord
+
+	keys := a.lookup.Keys()
+	for key := range a.counterIndex {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		data, val, err := a.getLocked([]byte(key))
+		if err != nil {
+			continue
+		}
+
+		records = append(records, liveRecord{key: key, data: append([]byte(nil), data...), val: val})
+	}
+
+	return records
+}
+
+// Clone returns new cache memory created with the same options which contains
+// copies of all valid records with their expiration time. Records are copied
+// under read lock and stored to the clone without write-through store, so the
+// clone is independent of the cache. Only references set in options (e.g.
+// store, loader, L2 cache or event bus) are shared. It is intended for tests,
+// which need to fork known cache state. The clone is read-only if the cache
+// is read-only. If keys are salted, then ErrKeysSalted is returned.
+func (a *AtomicCache) Clone() (*AtomicCache, error) {
+	if a.keysSalted() {
+		return nil, ErrKeysSalted
+	}
+
+	options := a.options
+	options.ReadOnly = false
+	clone, err := NewWithError(func(opts *Options) { *opts = options })
+	if err != nil {
+		return nil, err
+	}
+
+	a.RLock()
+	records := a.liveRecordsLocked()
+	a.RUnlock()
+
+	for _, record := range records {
+		if err := clone.setNoStore([]byte(record.key), record.data, record.val.OriginalTTL, record.val.Expiration, nil); err != nil {
+			clone.Close()
+			return nil, err
+		}
+	}
+	clone.options.ReadOnly = a.options.ReadOnly
+	clone.readOnly.Store(a.readOnly.Load())
+
+	return clone, nil
+}
@@ -0,0 +1,46 @@
+package atomiccache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheClone(t *testing.T) {
+	cache := New(OptionAtomicCounters(8))
+	cache.Set([]byte("key"), []byte("data"), 0)
+	cache.Set([]byte("counter"), []byte("5"), 0)
+	cache.Set([]byte("expired"), []byte("data"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	clone, err := cache.Clone()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if data, err := clone.Get([]byte("key")); err != nil || string(data) != "data" {
+		t.Errorf("%s, %v != data, <nil>", data, err)
+	}
+	if data, err := clone.Get([]byte("counter")); err != nil || string(data) != "5" {
+		t.Errorf("%s, %v != 5, <nil>", data, err)
+	}
+	if clone.Exists([]byte("expired")) {
+		t.Errorf("Expired record cloned")
+	}
+
+	src, _ := cache.GetMeta([]byte("key"))
+	dst, _ := clone.GetMeta([]byte("key"))
+	if !src.Expiration.Equal(dst.Expiration) {
+		t.Errorf("%v != %v", dst.Expiration, src.Expiration)
+	}
+
+	clone.Set([]byte("key"), []byte("clone"), 0)
+	clone.Incr([]byte("counter"))
+	cache.Delete([]byte("key"))
+
+	if data, err := clone.Get([]byte("key")); err != nil || string(data) != "clone" {
+		t.Errorf("%s, %v != clone, <nil>", data, err)
+	}
+	if data, _ := cache.Get([]byte("counter")); string(data) != "5" {
+		t.Errorf("%s != 5", data)
+	}
+}
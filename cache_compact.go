@@ -0,0 +1,67 @@
+package atomiccache
+
+import (
+	"sort"
+)
+
+// compactRecord represents record which can be moved by Compact.
+type compactRecord struct {
+	key    string
+	record LookupRecord
+}
+
+// Compact moves records from sparse shards to denser shards of the same
+// section and releases shards which end up empty. It returns number of
+// released shards. This is a stop-the-world operation, the write lock is held
+// for the whole time, so all other cache operations are blocked.
+func (a *AtomicCache) Compact() (shardsMerged int) {
+	a.Lock()
+	defer a.Unlock()
+
+	// Group records by section and shard
+	records := make([]map[uint32][]compactRecord, len(a.sections))
+	for i := range records {
+		records[i] = make(map[uint32][]compactRecord)
+	}
+	for _, key := range a.lookupKeysLocked() {
+		val, _ := a.lookup.Get(key)
+		val = a.withAccessLocked(val)
+		records[val.ShardSection-1][val.ShardIndex] = append(records[val.ShardSection-1][val.ShardIndex], compactRecord{key: key, record: val})
+	}
+
+	for i := range a.sections {
+		shardSection := &a.sections[i]
+
+		// Sort shards from the densest to the sparsest
+		active := append([]uint32(nil), shardSection.shardsActive...)
+		sort.SliceStable(active, func(x, y int) bool {
+			return shardSection.shards[active[x]].GetSlotsAvail() < shardSection.shards[active[y]].GetSlotsAvail()
+		})
+
+		dst, src := 0, len(active)-1
+		for dst < src {
+			srcShard := shardSection.shards[active[src]]
+			for _, r := range records[i][active[src]] {
+				for dst < src && shardSection.shards[active[dst]].GetSlotsAvail() == 0 {
+					dst++
+				}
+				if dst == src {
+					break
+				}
+
+				recordIndex := r.record.RecordIndex
+				r.record.RecordIndex = shardSection.shards[active[dst]].Set(srcShard.Get(recordIndex))
+				r.record.ShardIndex = active[dst]
+				srcShard.Free(recordIndex)
+				a.putLocked(r.key, r.record)
+			}
+
+			if a.releaseShard(uint8(i+1), active[src]) {
+				shardsMerged++
+			}
+			src--
+		}
+	}
+
+	return shardsMerged
+}
@@ -0,0 +1,33 @@
+package atomiccache
+
+import (
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestCacheCompact(t *testing.T) {
+	cache := New(OptionMaxRecords(4))
+	for i := 0; i < 12; i++ {
+		cache.Set([]byte(strconv.Itoa(i)), []byte(strconv.Itoa(i)), 0)
+	}
+
+	// Keep only one record in every shard
+	for _, i := range []int{1, 2, 3, 5, 6, 7, 9, 10, 11} {
+		cache.Delete([]byte(strconv.Itoa(i)))
+	}
+
+	if merged := cache.Compact(); merged != 2 {
+		t.Errorf("%v != %v", merged, 2)
+	}
+	if active := len(cache.sections[SMSH-1].shardsActive); active != 1 {
+		t.Errorf("%v != %v", active, 1)
+	}
+
+	for _, i := range []int{0, 4, 8} {
+		value, err := cache.Get([]byte(strconv.Itoa(i)))
+		if err != nil || !reflect.DeepEqual(value, []byte(strconv.Itoa(i))) {
+			t.Errorf("%v != %v", value, []byte(strconv.Itoa(i)))
+		}
+	}
+}
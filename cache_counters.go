@@ -0,0 +1,223 @@
+package atomiccache
+
+import (
+	"errors"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// ErrNotInteger is returned by Incr/Decr if record value is not an integer.
+var ErrNotInteger = errors.New("Record value is not an integer")
+
+// counterSection is shard section ID used in lookup records of counters.
+const counterSection uint8 = 0
+
+// counterRecord describes counter stored in counter section.
+type counterRecord struct {
+	index      uint32
+	expiration time.Time
+	ttl        time.Duration
+	version    uint32
+}
+
+// initCounters allocates counter section with specified number of counters.
+func (a *AtomicCache) initCounters(maxCounters uint32) {
+	a.counters = make([]atomic.Int64, maxCounters)
+	a.resetCountersLocked()
+}
+
+// resetCountersLocked removes all counters.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) resetCountersLocked() {
+	a.counterIndex = make(map[string]counterRecord)
+	a.counterFree = make([]uint32, 0, len(a.counters))
+	for i := range a.counters {
+		a.counterFree = append(a.counterFree, uint32(i))
+	}
+}
+
+// parseCounter returns integer value of data. Second value is false if data
+// are not an integer in canonical decimal form (so Get returns the same data
+// as were stored).
+func parseCounter(data []byte) (int64, bool) {
+	value, err := strconv.ParseInt(string(data), 10, 64)
+	if err != nil || strconv.FormatInt(value, 10) != string(data) {
+		return 0, false
+	}
+
+	return value, true
+}
+
+// getCounterLocked returns valid counter of the key.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) getCounterLocked(key string) (counterRecord, bool) {
+	if a.counters == nil {
+		return counterRecord{}, false
+	}
+
+	counter, ok := a.counterIndex[key]
+	if !ok || !isValid(counter.expiration, a.clock.Now()) || counter.version != a.version.Load() {
+		return counterRecord{}, false
+	}
+
+	return counter, true
+}
+
+// setCounterLocked stores value to counter section. Previous record of the key
+// is removed from shard sections. It returns false if counter section is full.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) setCounterLocked(key string, value int64, expire time.Duration, expiration time.Time) bool {
+	counter, ok := a.counterIndex[key]
+	if !ok {
+		if len(a.counterFree) == 0 {
+			return false
+		}
+		counter.index, a.counterFree = a.counterFree[0], a.counterFree[1:]
+	} else {
+		a.untagLocked(key)
+	}
+
+	if val, ok := a.lookup.Get(key); ok {
+		a.freeLocked(key, val)
+	}
+
+	counter.expiration, counter.ttl, counter.version = expiration, expire, a.version.Load()
+	a.counters[counter.index].Store(value)
+	a.counterIndex[key] = counter
+
+	return true
+}
+
+// deleteCounterLocked removes counter of the key. It returns false if there is
+// no such counter.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) deleteCounterLocked(key string) bool {
+	counter, ok := a.counterIndex[key]
+	if !ok {
+		return false
+	}
+
+	delete(a.counterIndex, key)
+	a.counterFree = append(a.counterFree, counter.index)
+	a.untagLocked(key)
+
+	return true
+}
+
+// Incr increments integer value of record by one and returns new value.
+func (a *AtomicCache) Incr(key []byte) (int64, error) {
+	return a.IncrBy(key, 1)
+}
+
+// Decr decrements integer value of record by one and returns new value.
+func (a *AtomicCache) Decr(key []byte) (int64, error) {
+	return a.IncrBy(key, -1)
+}
+
+// DecrBy decrements integer value of record by delta and returns new value.
+func (a *AtomicCache) DecrBy(key []byte, delta int64) (int64, error) {
+	return a.IncrBy(key, -delta)
+}
+
+// IncrBy increments integer value of record by delta and returns new value.
+// Missing record is created with default expiration and value equal to delta.
+// Expiration of existing record is kept. If record value is not an integer,
+// then ErrNotInteger is returned. If counter section is enabled, existing
+// counter is updated atomically under read lock only.
+func (a *AtomicCache) IncrBy(key []byte, delta int64) (int64, error) {
+	if err := a.checkKey(key); err != nil {
+		return 0, err
+	}
+
+	if err := a.rlockWritable(); err != nil {
+		return 0, err
+	}
+	if counter, ok := a.getCounterLocked(string(key)); ok {
+		value := a.counters[counter.index].Add(delta)
+		a.RUnlock()
+		a.notifyWatchers(WatchEvent{Type: EventSet, Key: key, Data: strconv.AppendInt(nil, value, 10)})
+		return value, nil
+	}
+	a.RUnlock()
+
+	if err := a.lockWritable(); err != nil {
+		return 0, err
+	}
+	value, collectGarbage, err := a.incrLocked(key, delta)
+	a.Unlock()
+
+	if collectGarbage {
+		a.startGarbageCollection()
+	}
+
+	return value, err
+}
+
+// incrLocked increments integer value of record by delta. It returns new value
+// and true if garbage collection is required.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) incrLocked(key []byte, delta int64) (int64, bool, error) {
+	var value int64
+
+	expire, expiration := time.Duration(0), a.getExprTime(0)
+
+	data, val, err := a.getLocked(key)
+	if err == nil {
+		var ok bool
+		if value, ok = parseCounter(data); !ok {
+			return 0, false, ErrNotInteger
+		}
+		expire, expiration = val.OriginalTTL, val.Expiration
+	} else if err != ErrNotFound {
+		return 0, false, err
+	}
+
+	value += delta
+	collectGarbage, err := a.setLocked(key, []byte(strconv.FormatInt(value, 10)), expire, expiration)
+	if err != nil {
+		return 0, false, err
+	}
+
+	return value, collectGarbage, nil
+}
+
+// GetAndReset atomically returns data of record and resets it. Integer value is
+// replaced by zero and expiration of record is kept. Other records are removed
+// same way as in GetAndDelete. If record is not found or it is expired, then
+// error is returned.
+func (a *AtomicCache) GetAndReset(key []byte) ([]byte, error) {
+	if err := a.checkKey(key); err != nil {
+		return nil, err
+	}
+
+	if err := a.lockWritable(); err != nil {
+		return nil, err
+	}
+	defer a.Unlock()
+
+	data, val, err := a.getLocked(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if val.ShardSection == counterSection {
+		a.watchLocked(EventSet, string(key), []byte("0"))
+		return strconv.AppendInt(nil, a.counters[val.RecordIndex].Swap(0), 10), nil
+	}
+
+	data = append([]byte{}, data...)
+	if _, ok := parseCounter(data); !ok {
+		a.deleteLocked(string(key), val)
+		return data, nil
+	}
+
+	// Zero is stored to counter section or to the section of previous record,
+	// whose slot is freed first. It is buffered only if the freed slot is kept
+	// for reservation holders (see Reserve).
+	if _, err := a.setLocked(key, []byte("0"), val.OriginalTTL, val.Expiration); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
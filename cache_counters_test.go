@@ -0,0 +1,205 @@
+package atomiccache
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCacheIncr(t *testing.T) {
+	for _, counters := range []uint32{0, 16} {
+		cache := New(OptionAtomicCounters(counters))
+
+		for i, c := range []struct {
+			fn   func([]byte) (int64, error)
+			want int64
+		}{
+			{cache.Incr, 1},
+			{cache.Incr, 2},
+			{cache.Decr, 1},
+			{func(key []byte) (int64, error) { return cache.IncrBy(key, 10) }, 11},
+			{func(key []byte) (int64, error) { return cache.DecrBy(key, 20) }, -9},
+		} {
+			if value, err := c.fn([]byte("counter")); err != nil || value != c.want {
+				t.Errorf("[%d:%d] %v != %v", counters, i, value, c.want)
+			}
+		}
+
+		if data, err := cache.Get([]byte("counter")); err != nil || !reflect.DeepEqual(data, []byte("-9")) {
+			t.Errorf("[%d] %q != %q", counters, data, "-9")
+		}
+
+		cache.Set([]byte("text"), []byte("data"), 0)
+		if _, err := cache.Incr([]byte("text")); err != ErrNotInteger {
+			t.Errorf("[%d] Expecting error 'ErrNotInteger'", counters)
+		}
+	}
+}
+
+func TestCacheCounterSection(t *testing.T) {
+	cache := New(OptionAtomicCounters(1))
+
+	// Integer value is routed to counter section
+	cache.Set([]byte("counter"), []byte("41"), 0)
+	if _, ok := cache.counterIndex["counter"]; !ok || cache.lookup.Size() != 0 {
+		t.Errorf("Integer value is not stored to counter section")
+	}
+
+	// Counter section is full, so shard section is used
+	cache.Set([]byte("other"), []byte("1"), 0)
+	if _, ok := cache.lookup.Get("other"); !ok {
+		t.Errorf("Integer value is not stored to shard section")
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cache.Incr([]byte("counter"))
+		}()
+	}
+	wg.Wait()
+
+	if data, err := cache.Get([]byte("counter")); err != nil || string(data) != "141" {
+		t.Errorf("%q != %q", data, "141")
+	}
+
+	// Non-integer value replaces counter
+	cache.Set([]byte("counter"), []byte("data"), 0)
+	if _, ok := cache.counterIndex["counter"]; ok {
+		t.Errorf("Counter is not removed")
+	}
+	if data, err := cache.Get([]byte("counter")); err != nil || string(data) != "data" {
+		t.Errorf("%q != %q", data, "data")
+	}
+
+	cache.Set([]byte("counter2"), []byte("5"), 0)
+	if err := cache.Delete([]byte("counter2")); err != nil || cache.Exists([]byte("counter2")) {
+		t.Errorf("Counter is not deleted")
+	}
+}
+
+func TestCacheGetAndReset(t *testing.T) {
+	for _, counters := range []uint32{0, 16} {
+		cache := New(OptionAtomicCounters(counters))
+
+		cache.IncrBy([]byte("counter"), 5)
+		cache.Set([]byte("key"), []byte("data"), 0)
+
+		if data, err := cache.GetAndReset([]byte("counter")); err != nil || !reflect.DeepEqual(data, []byte("5")) {
+			t.Errorf("[%d] %q != %q", counters, data, "5")
+		}
+		if data, err := cache.Get([]byte("counter")); err != nil || !reflect.DeepEqual(data, []byte("0")) {
+			t.Errorf("[%d] %q != %q", counters, data, "0")
+		}
+
+		if data, err := cache.GetAndReset([]byte("key")); err != nil || !reflect.DeepEqual(data, []byte("data")) {
+			t.Errorf("[%d] %q != %q", counters, data, "data")
+		}
+		if cache.Exists([]byte("key")) {
+			t.Errorf("[%d] Non-integer record is not removed", counters)
+		}
+
+		if _, err := cache.GetAndReset([]byte("missing")); err != ErrNotFound {
+			t.Errorf("Expecting error 'ErrNotFound'")
+		}
+	}
+}
+
+func TestCacheCounterMethods(t *testing.T) {
+	newCache := func() *AtomicCache {
+		cache := New(OptionAtomicCounters(4))
+		cache.Set([]byte("counter"), []byte("7"), time.Minute)
+		if _, ok := cache.counterIndex["counter"]; !ok {
+			t.Fatalf("Integer value is not stored to counter section")
+		}
+		return cache
+	}
+
+	for name, fn := range map[string]func(*AtomicCache) error{
+		"Update": func(cache *AtomicCache) error {
+			return cache.Update([]byte("counter"), func(current []byte) ([]byte, error) {
+				if string(current) != "7" {
+					t.Errorf("%q != %q", current, "7")
+				}
+				return []byte("8"), nil
+			})
+		},
+		"Expire": func(cache *AtomicCache) error {
+			return cache.Expire([]byte("counter"), time.Hour)
+		},
+		"IncrExpire": func(cache *AtomicCache) error {
+			_, err := cache.IncrExpire([]byte("counter"), time.Hour)
+			return err
+		},
+		"Touch": func(cache *AtomicCache) error {
+			return cache.Touch([]byte("counter"), time.Hour)
+		},
+		"Type": func(cache *AtomicCache) error {
+			typ, err := cache.Type([]byte("counter"))
+			if err == nil && typ != "counter" {
+				t.Errorf("%v != %v", typ, "counter")
+			}
+			return err
+		},
+		"GetMeta": func(cache *AtomicCache) error {
+			meta, err := cache.GetMeta([]byte("counter"))
+			if err == nil && meta.ShardSection != counterSection {
+				t.Errorf("%v != %v", meta.ShardSection, counterSection)
+			}
+			return err
+		},
+	} {
+		if err := fn(newCache()); err != nil {
+			t.Errorf("[%s] %v", name, err)
+		}
+	}
+
+	// Expiration of counter is updated
+	cache := newCache()
+	cache.Expire([]byte("counter"), time.Hour)
+	if ttl, err := cache.TTL([]byte("counter")); err != nil || ttl <= time.Minute {
+		t.Errorf("Counter expiration is not updated: %v", ttl)
+	}
+	if updated := cache.ExpireIf(func(key []byte, meta LookupRecord) bool { return true }, 2*time.Hour); updated != 1 {
+		t.Errorf("%v != %v", updated, 1)
+	}
+	if ttl, _ := cache.TTL([]byte("counter")); ttl <= time.Hour {
+		t.Errorf("Counter expiration is not updated by ExpireIf: %v", ttl)
+	}
+
+	// Counters are dumped
+	var buf bytes.Buffer
+	if err := cache.Dump(&buf); err != nil {
+		t.Fatal(err)
+	}
+	restored := New()
+	if loaded, err := restored.WarmUp(&buf); err != nil || loaded != 1 {
+		t.Errorf("%v != %v", loaded, 1)
+	}
+	if data, err := restored.Get([]byte("counter")); err != nil || string(data) != "7" {
+		t.Errorf("%q != %q", data, "7")
+	}
+
+	// Counters are removed by prefix
+	cache.Set([]byte("other"), []byte("1"), 0)
+	if deleted, _ := cache.DeletePrefix([]byte("counter")); deleted != 1 {
+		t.Errorf("%v != %v", deleted, 1)
+	}
+	if cache.Exists([]byte("counter")) || !cache.Exists([]byte("other")) {
+		t.Errorf("Counter is not removed by prefix")
+	}
+
+	// Stale counter is not left behind by SetFromReader
+	cache = newCache()
+	if err := cache.SetFromReader([]byte("counter"), strings.NewReader("data"), 4, 0); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := cache.Get([]byte("counter")); err != nil || string(data) != "data" {
+		t.Errorf("%q != %q", data, "data")
+	}
+}
@@ -0,0 +1,91 @@
+package atomiccache
+
+import (
+	"encoding/gob"
+	"io"
+	"time"
+)
+
+// dumpRecord is a record of gob stream produced by Dump.
+type dumpRecord struct {
+	Key        []byte
+	Data       []byte
+	Expiration time.Time
+}
+
+// Dump writes all valid records to writer as gob stream. Records are copied
+// under read lock first, so the lock is not held while writing. The stream
+// can be loaded by WarmUp. If keys are salted, then ErrKeysSalted is returned.
+func (a *AtomicCache) Dump(w io.Writer) error {
+	_, err := a.dump(w, func(LookupRecord) bool { return true })
+	return err
+}
+
+// DumpSection writes valid records of one shard section to writer same way as
+// Dump and returns number of written records. The stream can be loaded by
+// WarmUp, so section can be restored separately. If keys are salted, then
+// ErrKeysSalted is returned.
+func (a *AtomicCache) DumpSection(w io.Writer, section uint8) (int, error) {
+	return a.dump(w, func(val LookupRecord) bool { return val.ShardSection == section })
+}
+
+// dump writes valid records accepted by filter to writer as gob stream and
+// returns number of written records.
+func (a *AtomicCache) dump(w io.Writer, filter func(val LookupRecord) bool) (int, error) {
+	var records []dumpRecord
+
+	if a.keysSalted() {
+		return 0, ErrKeysSalted
+	}
+
+	a.RLock()
+	for _, record := range a.liveRecordsLocked() {
+		if filter(record.val) {
+			records = append(records, dumpRecord{Key: []byte(record.key), Data: record.data, Expiration: record.val.Expiration})
+		}
+	}
+	a.RUnlock()
+
+	enc := gob.NewEncoder(w)
+	for i := range records {
+		if err := enc.Encode(&records[i]); err != nil {
+			return i, err
+		}
+	}
+
+	return len(records), nil
+}
+
+// WarmUp reads gob stream produced by Dump and stores its records to cache
+// memory. Records are stored same way as by SetNoStore (write-through store
+// is bypassed), already expired records are skipped. Records which cannot be
+// stored (e.g. because of data limit) are skipped too. It returns number of
+// stored records and error if the stream cannot be decoded.
+func (a *AtomicCache) WarmUp(r io.Reader) (int, error) {
+	var loaded int
+
+	if err := a.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	dec := gob.NewDecoder(r)
+	for {
+		var record dumpRecord
+		if err := dec.Decode(&record); err == io.EOF {
+			return loaded, nil
+		} else if err != nil {
+			return loaded, err
+		}
+
+		expire := NoExpiry
+		if !record.Expiration.IsZero() {
+			if expire = record.Expiration.Sub(a.clock.Now()); expire <= 0 {
+				continue
+			}
+		}
+
+		if err := a.SetNoStore(record.Key, record.Data, expire); err == nil {
+			loaded++
+		}
+	}
+}
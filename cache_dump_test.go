@@ -0,0 +1,70 @@
+package atomiccache
+
+import (
+	"bytes"
+	"encoding/gob"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCacheDumpWarmUp(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key1"), []byte("data1"), 0)
+	cache.Set([]byte("key2"), []byte("data2"), NoExpiry)
+
+	var buf bytes.Buffer
+	if err := cache.Dump(&buf); err != nil {
+		t.Fatalf("Dump error: %s", err.Error())
+	}
+
+	warm := New()
+	if loaded, err := warm.WarmUp(&buf); err != nil || loaded != 2 {
+		t.Errorf("%v != %v", loaded, 2)
+	}
+
+	for _, key := range []string{"key1", "key2"} {
+		expected, _ := cache.Get([]byte(key))
+		if data, err := warm.Get([]byte(key)); err != nil || !reflect.DeepEqual(data, expected) {
+			t.Errorf("%q != %q", data, expected)
+		}
+	}
+	if ttl, err := warm.TTL([]byte("key2")); err != nil || ttl != NoExpiry {
+		t.Errorf("%v != %v", ttl, NoExpiry)
+	}
+}
+
+func TestCacheWarmUpExpired(t *testing.T) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	enc.Encode(&dumpRecord{Key: []byte("expired"), Data: []byte("data"), Expiration: time.Now().Add(-time.Second)})
+	enc.Encode(&dumpRecord{Key: []byte("valid"), Data: []byte("data"), Expiration: time.Now().Add(time.Hour)})
+
+	cache := New()
+	if loaded, err := cache.WarmUp(&buf); err != nil || loaded != 1 {
+		t.Errorf("%v != %v", loaded, 1)
+	}
+	if cache.Exists([]byte("expired")) || !cache.Exists([]byte("valid")) {
+		t.Errorf("Unexpected records after warm up")
+	}
+}
+
+func TestCacheDumpSection(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("small"), []byte("data"), 0)
+	cache.Set([]byte("large1"), make([]byte, cache.RecordSizeLarge), 0)
+	cache.Set([]byte("large2"), make([]byte, cache.RecordSizeLarge), 0)
+
+	var buf bytes.Buffer
+	if written, err := cache.DumpSection(&buf, LGSH); err != nil || written != 2 {
+		t.Errorf("%v != %v", written, 2)
+	}
+
+	warm := New()
+	if loaded, err := warm.WarmUp(&buf); err != nil || loaded != 2 {
+		t.Errorf("%v != %v", loaded, 2)
+	}
+	if warm.Exists([]byte("small")) || !warm.Exists([]byte("large1")) {
+		t.Errorf("Records of different section are restored")
+	}
+}
@@ -0,0 +1,121 @@
+package atomiccache
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"time"
+)
+
+// LockKey acquires lock represented by record of the key. If the key is not
+// present, record with unique token (random hex string and hostname) is stored
+// with specified expiration and the token is returned with true. If the key is
+// already present, lock is held by someone else and false is returned. The
+// token is required to release or extend the lock. Lock record is never stored
+// to buffer, so ErrFullMemory is returned if cache memory is full.
+func (a *AtomicCache) LockKey(key []byte, ttl time.Duration) ([]byte, bool, error) {
+	if err := a.checkKey(key); err != nil {
+		return nil, false, err
+	}
+
+	token, err := newLockToken()
+	if err != nil {
+		return nil, false, err
+	}
+
+	if err := a.lockWritable(); err != nil {
+		return nil, false, err
+	}
+	defer a.Unlock()
+
+	if _, _, err := a.getLocked(key); err == nil {
+		return nil, false, nil
+	} else if err != ErrNotFound {
+		return nil, false, err
+	}
+
+	if a.valueValidator != nil {
+		if err := a.valueValidator(key, token); err != nil {
+			return nil, false, err
+		}
+	}
+	if !a.storeLocked(key, token, ttl, a.getExprTime(ttl)) {
+		return nil, false, ErrFullMemory
+	}
+
+	return token, true, nil
+}
+
+// UnlockKey releases lock of the key. Record is deleted only if it contains
+// the token, otherwise (lock expired or it is held by someone else) false is
+// returned.
+func (a *AtomicCache) UnlockKey(key []byte, token []byte) (bool, error) {
+	if err := a.checkKey(key); err != nil {
+		return false, err
+	}
+
+	if err := a.lockWritable(); err != nil {
+		return false, err
+	}
+	defer a.Unlock()
+
+	val, ok, err := a.lockRecordLocked(key, token)
+	if !ok {
+		return false, err
+	}
+
+	a.deleteLocked(string(key), val)
+
+	return true, nil
+}
+
+// ExtendLock sets new expiration of lock. Expiration is changed only if record
+// contains the token, otherwise (lock expired or it is held by someone else)
+// false is returned.
+func (a *AtomicCache) ExtendLock(key []byte, token []byte, ttl time.Duration) (bool, error) {
+	if err := a.checkKey(key); err != nil {
+		return false, err
+	}
+
+	if err := a.lockWritable(); err != nil {
+		return false, err
+	}
+	defer a.Unlock()
+
+	if _, ok, err := a.lockRecordLocked(key, token); !ok {
+		return false, err
+	}
+
+	if err := a.expireLocked(key, ttl); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// lockRecordLocked returns lookup record of lock if it contains the token.
+// Second value is false if lock is not held with the token.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) lockRecordLocked(key []byte, token []byte) (LookupRecord, bool, error) {
+	data, val, err := a.getLocked(key)
+	if err == ErrNotFound {
+		return LookupRecord{}, false, nil
+	} else if err != nil {
+		return LookupRecord{}, false, err
+	}
+
+	return val, bytes.Equal(data, token), nil
+}
+
+// newLockToken returns unique lock token.
+func newLockToken() ([]byte, error) {
+	random := make([]byte, 16)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+
+	hostname, _ := os.Hostname()
+
+	return []byte(hex.EncodeToString(random) + "@" + hostname), nil
+}
@@ -0,0 +1,41 @@
+package atomiccache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheLockKey(t *testing.T) {
+	cache := New()
+
+	token, ok, err := cache.LockKey([]byte("lock"), time.Minute)
+	if err != nil || !ok || len(token) == 0 {
+		t.Errorf("Lock is not acquired: %v", err)
+	}
+
+	if _, ok, _ := cache.LockKey([]byte("lock"), time.Minute); ok {
+		t.Errorf("Lock is acquired twice")
+	}
+
+	if ok, _ := cache.UnlockKey([]byte("lock"), []byte("other")); ok {
+		t.Errorf("Lock is released with different token")
+	}
+
+	if ok, _ := cache.ExtendLock([]byte("lock"), token, time.Hour); !ok {
+		t.Errorf("Lock is not extended")
+	}
+	if ttl, _ := cache.TTL([]byte("lock")); ttl <= time.Minute {
+		t.Errorf("TTL %v is not extended", ttl)
+	}
+
+	if ok, _ := cache.UnlockKey([]byte("lock"), token); !ok {
+		t.Errorf("Lock is not released")
+	}
+	if ok, _ := cache.ExtendLock([]byte("lock"), token, time.Hour); ok {
+		t.Errorf("Released lock is extended")
+	}
+
+	if _, ok, _ := cache.LockKey([]byte("lock"), time.Minute); !ok {
+		t.Errorf("Released lock is not acquired")
+	}
+}
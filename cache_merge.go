@@ -0,0 +1,104 @@
+package atomiccache
+
+import (
+	"unsafe"
+)
+
+// ConflictPolicy specifies how MergeFrom handles keys which are already
+// present in target cache.
+type ConflictPolicy int
+
+// Conflict policies of MergeFrom.
+const (
+	// SkipExisting keeps existing record.
+	SkipExisting ConflictPolicy = iota
+	// OverwriteExisting replaces existing record.
+	OverwriteExisting
+	// KeepLongerTTL keeps record which expires later (record without
+	// expiration is the longest one).
+	KeepLongerTTL
+)
+
+// MergeFrom stores copies of all valid records of source cache to cache
+// memory. Records keep their expiration time. Keys which are already present
+// are handled according to conflict policy. Both caches are locked during the
+// whole operation (locks are acquired in address order, so concurrent merges
+// in opposite directions do not deadlock). Write-through store is not used.
+// It returns number of merged records and number of skipped records (existing
+// or rejected by key, data limit or value validator). If record cannot be
+// stored (e.g. memory is full), merge stops and error is returned. If keys of
+// source cache are salted, then ErrKeysSalted is returned.
+func (a *AtomicCache) MergeFrom(src *AtomicCache, conflictPolicy ConflictPolicy) (merged, skipped int, err error) {
+	if src == a {
+		return 0, 0, nil
+	}
+
+	if src.keysSalted() {
+		return 0, 0, ErrKeysSalted
+	}
+
+	if err := a.checkWritable(); err != nil {
+		return 0, 0, err
+	}
+
+	if uintptr(unsafe.Pointer(a)) < uintptr(unsafe.Pointer(src)) {
+		a.Lock()
+		src.RLock()
+	} else {
+		src.RLock()
+		a.Lock()
+	}
+	// Read-only mode may be enabled while the lock was awaited
+	if err := a.checkWritable(); err != nil {
+		src.RUnlock()
+		a.Unlock()
+		return 0, 0, err
+	}
+	records := src.liveRecordsLocked()
+	src.RUnlock()
+
+	collectGarbage := false
+	for _, record := range records {
+		key := []byte(record.key)
+		if a.checkKey(key) != nil || len(record.data) > int(a.maxItemSize) {
+			skipped++
+			continue
+		}
+		if a.valueValidator != nil && a.valueValidator(key, record.data) != nil {
+			skipped++
+			continue
+		}
+
+		if _, current, err := a.getLocked(key); err == nil && !replaceOnConflict(conflictPolicy, current, record.val) {
+			skipped++
+			continue
+		}
+
+		var gc bool
+		if gc, err = a.setLocked(key, record.data, record.val.OriginalTTL, record.val.Expiration); err != nil {
+			break
+		}
+		collectGarbage = collectGarbage || gc
+		merged++
+	}
+	a.Unlock()
+
+	if collectGarbage {
+		a.startGarbageCollection()
+	}
+
+	return merged, skipped, err
+}
+
+// replaceOnConflict returns true if existing record has to be replaced by new
+// record according to conflict policy.
+func replaceOnConflict(conflictPolicy ConflictPolicy, current, record LookupRecord) bool {
+	switch conflictPolicy {
+	case OverwriteExisting:
+		return true
+	case KeepLongerTTL:
+		return !current.Expiration.IsZero() && (record.Expiration.IsZero() || record.Expiration.After(current.Expiration))
+	default:
+		return false
+	}
+}
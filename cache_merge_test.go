@@ -0,0 +1,70 @@
+package atomiccache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCacheMergeFrom(t *testing.T) {
+	for _, tc := range []struct {
+		policy         ConflictPolicy
+		short, forever string
+		merged         int
+	}{
+		{SkipExisting, "dst", "dst", 1},
+		{OverwriteExisting, "src", "src", 3},
+		{KeepLongerTTL, "src", "dst", 2},
+	} {
+		src, dst := New(), New()
+		src.Set([]byte("new"), []byte("src"), 0)
+		src.Set([]byte("short"), []byte("src"), time.Hour)
+		src.Set([]byte("forever"), []byte("src"), time.Hour)
+		src.Set([]byte("expired"), []byte("src"), time.Nanosecond)
+		dst.Set([]byte("short"), []byte("dst"), time.Minute)
+		dst.Set([]byte("forever"), []byte("dst"), NoExpiry)
+		time.Sleep(time.Millisecond)
+
+		merged, skipped, err := dst.MergeFrom(src, tc.policy)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if merged != tc.merged || skipped != 3-tc.merged {
+			t.Errorf("%v, %v != %v, %v", merged, skipped, tc.merged, 3-tc.merged)
+		}
+
+		if data, _ := dst.Get([]byte("new")); string(data) != "src" {
+			t.Errorf("%s != src", data)
+		}
+		if data, _ := dst.Get([]byte("short")); string(data) != tc.short {
+			t.Errorf("%s != %s", data, tc.short)
+		}
+		if data, _ := dst.Get([]byte("forever")); string(data) != tc.forever {
+			t.Errorf("%s != %s", data, tc.forever)
+		}
+		if dst.Exists([]byte("expired")) {
+			t.Errorf("Expired record merged")
+		}
+	}
+}
+
+func TestCacheMergeFromConcurrent(t *testing.T) {
+	a, b := New(), New()
+	a.Set([]byte("a"), []byte("data"), 0)
+	b.Set([]byte("b"), []byte("data"), 0)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 100; i++ {
+			a.MergeFrom(b, OverwriteExisting)
+		}
+		close(done)
+	}()
+	for i := 0; i < 100; i++ {
+		b.MergeFrom(a, OverwriteExisting)
+	}
+	<-done
+
+	if !a.Exists([]byte("b")) || !b.Exists([]byte("a")) {
+		t.Errorf("Records are not merged")
+	}
+}
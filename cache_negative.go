@@ -0,0 +1,33 @@
+package atomiccache
+
+import (
+	"time"
+)
+
+// SetNegative stores negative record of the key. Negative record says that the
+// key is known to be missing (e.g. in backing store), so repeated lookups can
+// be prevented. It does not affect Get, which still returns ErrNotFound. The
+// negative record is removed by Set, Delete, Flush or garbage collector after
+// expiration.
+func (a *AtomicCache) SetNegative(key []byte, expire time.Duration) error {
+	if err := a.checkKey(key); err != nil {
+		return err
+	}
+
+	if err := a.lockWritable(); err != nil {
+		return err
+	}
+	a.negativeKeys[string(key)] = a.getExprTime(expire)
+	a.Unlock()
+
+	return nil
+}
+
+// IsNegative returns true if valid negative record of the key exists.
+func (a *AtomicCache) IsNegative(key []byte) bool {
+	a.RLock()
+	expiration, ok := a.negativeKeys[string(key)]
+	a.RUnlock()
+
+	return ok && isValid(expiration, a.clock.Now())
+}
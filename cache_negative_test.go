@@ -0,0 +1,37 @@
+package atomiccache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCacheNegative(t *testing.T) {
+	cache := New()
+	cache.SetNegative([]byte("key"), 0)
+
+	if !cache.IsNegative([]byte("key")) {
+		t.Errorf("Expecting negative record")
+	}
+	if _, err := cache.Get([]byte("key")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+	if err := cache.Delete([]byte("key")); err != nil || cache.IsNegative([]byte("key")) {
+		t.Errorf("Negative record was not deleted")
+	}
+}
+
+func TestCacheNegativeExpiration(t *testing.T) {
+	cache := New()
+	cache.SetNegative([]byte("key"), 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	if cache.IsNegative([]byte("key")) {
+		t.Errorf("Negative record is expired, but still valid")
+	}
+
+	cache.collectGarbage()
+	if len(cache.negativeKeys) != 0 {
+		t.Errorf("%v != %v", len(cache.negativeKeys), 0)
+	}
+}
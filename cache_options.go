@@ -1,5 +1,35 @@
 package atomiccache
 
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"sort"
+	"time"
+)
+
+// Record size limits.
+const (
+	// MinRecordSize is minimal size of small record (it has to hold int64).
+	MinRecordSize = 8
+	// MaxRecordSize is maximal size of large record (64 MiB).
+	MaxRecordSize = 64 << 20
+)
+
+// Options validation errors
+var (
+	ErrRecordSizeOrder = errors.New("Record sizes must be ordered: small < medium < large")
+	ErrRecordSizeMin   = errors.New("Small record size is lower than minimal record size")
+	ErrRecordSizeMax   = errors.New("Large record size is greater than maximal record size")
+	ErrTiers           = errors.New("There must be 1 to 255 tiers with at least one shard")
+	ErrBTreeDegree     = errors.New("BTree degree must be at least 3")
+)
+
+// unsetBufferSize marks that maximum buffer size was not set by option.
+const unsetBufferSize = math.MaxUint32
+
 // Options are used for AtomicCache construct function.
 type Options struct {
 	// Size of byte array used for memory allocation at small shard section.
@@ -18,6 +48,207 @@ type Options struct {
 	MaxShardsLarge uint32
 	// Garbage collector starter (run garbage collection every X sets).
 	GcStarter uint32
+	// Logger used for garbage collection and buffer events (disabled if nil).
+	Logger *slog.Logger
+	// Lookup table implementation (hash map if nil).
+	LookupBackend LookupBackend
+	// Extend lifetime of record on every successful Get.
+	SlidingExpiration bool
+	// Maximum random duration added to expiration time (disabled if 0).
+	ExpirationJitter time.Duration
+	// Expiration duration used if record is stored with zero duration.
+	DefaultTTL time.Duration
+	// Function used to load missing records on Get (disabled if nil).
+	Loader func(ctx context.Context, key []byte) ([]byte, time.Duration, error)
+	// Function used to persist records on Set (disabled if nil).
+	Store func(ctx context.Context, key, value []byte) error
+	// Function called if Store fails.
+	OnStoreError func(key []byte, err error)
+	// Custom shard sections (tiers), which replace small, medium and large
+	// sections.
+	CustomTiers []TierConfig
+	// Minimum key length (empty keys are never allowed).
+	MinKeyLength int
+	// Maximum key length (not limited if 0).
+	MaxKeyLength int
+	// Function used to validate data before they are stored (disabled if nil).
+	ValueValidator func(key, value []byte) error
+	// Policy used to evict records if shards section is full (buffer is used
+	// if nil).
+	EvictionPolicy EvictionPolicy
+	// Use circular buffer (lossy mode), which overwrites the oldest unattended
+	// request if it is full, instead of returning ErrFullMemory (error mode).
+	CircularBuffer bool
+	// Function called for every request dropped because of full buffer (the
+	// new request in error mode, the oldest one in lossy mode). It is called
+	// while cache lock is held, so it must not call cache methods.
+	OnBufferFull func(dropped BufferItem)
+	// Store CRC32 checksum with every record (4 bytes per record) and verify
+	// it on Get (ErrChecksumMismatch is returned if verification fails).
+	Checksums bool
+	// Memory pool shared by multiple caches, which is used for shard
+	// allocation (shards allocate their own memory if nil). If it is set,
+	// data returned by Get are always copies, because shard memory can be
+	// reused by another cache after release.
+	Pool *SharedPool
+	// Metrics recorder used to export cache metrics to any metrics backend
+	// (NoopMetrics if nil).
+	Metrics MetricsRecorder
+	// Allocate all shards at initialization instead of lazy allocation. It
+	// trades memory for predictable latency, each section allocates
+	// maxShards * maxRecords * recordSize bytes upfront (e.g. default small
+	// section allocates 256 * 2048 * 512 B = 256 MiB).
+	EagerAllocation bool
+	// Start garbage collection if slot utilization (used/total) of shard
+	// section exceeds this ratio after Set, e.g. 0.9 (disabled if 0).
+	GcUtilizationThreshold float64
+	// Number of counters in dedicated counter section (disabled if 0).
+	// Integer values are stored to the section by Set and Incr/Decr update
+	// them atomically without shard sections and lookup table. Counters are
+	// visible to all key-addressed methods (e.g. Get, Expire, Touch, Type or
+	// GetMeta), to Scan, DeletePrefix, Dump and garbage collector.
+	AtomicCounters uint32
+	// Writer of debug trace, which gets one line for every Set, Get, Delete,
+	// eviction and garbage collection (disabled if nil). The writer is locked
+	// for every line, so it should not be used in production.
+	DebugTrace io.Writer
+	// Degree of BTree lookup backend, which is used instead of hash map if
+	// it is set and no lookup backend is specified. Higher degree means lower
+	// tree and fewer pointer chases, but larger nodes (more memory per node
+	// and more copying on insert). Large caches (millions of keys) benefit
+	// from degree 32 or 64. The BTree implementation requires at least 3.
+	BTreeDegree int
+	// Initial cache version. Every record is stamped with current version
+	// and records of different version are treated as missing (see Bump).
+	Version uint32
+	// Channel which receives copy of every key removed by garbage collector
+	// because of expiration (explicit deletes and evictions are not reported).
+	// Send does not block garbage collector, so notification is dropped if
+	// channel is full. The channel should be buffered.
+	ExpirationNotify chan<- []byte
+	// Adaptive garbage collector starter. After every garbage collection, the
+	// starter is halved if more than 50 % of scanned records were evicted
+	// and doubled if less than 1 % of them were evicted. It stays between
+	// MinGcStarter and MaxGcStarter, GcStarter is the initial value.
+	AdaptiveGC bool
+	// Event bus which receives event of every Set, Get, Delete and eviction
+	// (disabled if nil).
+	EventBus EventBus
+	// Hash keys by FNV-1a before they are stored to lookup backend (see
+	// HashedLookup). It evens out BTree distribution of keys with common
+	// prefixes, but ordered range iteration cannot be used. It is ignored if
+	// KeySalt is set, because salted keys are evenly distributed already.
+	HashKeys bool
+	// Seed of key hash if HashKeys is enabled. If it is zero, random seed is
+	// used. Fixed seed makes key distribution reproducible across restarts.
+	HashSeed uint64
+	// Secret salt used for HMAC-SHA256 of keys before they are stored to
+	// lookup table (disabled if nil, see SaltedLookup). Original keys are
+	// never stored in lookup table, so they cannot be listed. Scan, ExpireIf,
+	// GetKeysExpiring and Snapshot skip records of lookup table and Clone,
+	// Dump, MergeFrom (of salted source), DeletePrefix and RandomKey return
+	// ErrKeysSalted. Keys of counters, tags and negative records are not
+	// hashed.
+	KeySalt []byte
+	// Second level cache (disabled if nil). If record is not found in cache
+	// memory, it is taken from L2 cache and stored locally with remaining
+	// TTL of L2 record (before loader is called). Set writes the record to L2
+	// cache in separate goroutine. Other operations (e.g. Delete) are local
+	// only. L2 errors are logged, they never fail local operation.
+	L2Cache Cache
+	// Maximum number of unattended set requests in buffer (MaxRecords if it
+	// is not set). Large buffer keeps writes which do not fit to full cache
+	// memory until garbage collection frees space, but they are applied with
+	// delay and lost if they are dropped. Small buffer fails fast with
+	// ErrFullMemory. Buffer is disabled if it is 0, so Set returns
+	// ErrFullMemory immediately if memory is full (even in circular mode).
+	MaxBufferSize uint32
+	// Maximum data size accepted by Set (RecordSizeLarge if it is 0). Record
+	// sizes control slot allocation of shards, while this option controls
+	// acceptance. If it is lower than RecordSizeLarge, larger data are
+	// rejected with ErrDataLimit. If it is greater, data larger than
+	// RecordSizeLarge are stored to overflow section, which has shards with
+	// one record of maximum item size (up to MaxShardsLarge shards).
+	MaxItemSize uint32
+	// If tier promotion is enabled, records which do not fit to full section
+	// are stored to larger section (except overflow section) instead of
+	// buffer. Records accessed more than TierPromotionThreshold times are
+	// moved to the smallest section where they fit (if it has space).
+	TierPromotion bool
+	// Number of accesses after which record is promoted (default 10).
+	TierPromotionThreshold uint32
+	// Fraction of original TTL. If loader is set and threshold is greater
+	// than 0, records whose remaining TTL is lower than the fraction are
+	// reloaded by loader in background (disabled by default).
+	PrefetchThreshold float64
+	// Interval of background prefetch scan (default 1 minute).
+	PrefetchInterval time.Duration
+	// Start cache in read-only mode (see SetReadOnly).
+	ReadOnly bool
+	// Source of time used for expiration of records (RealClock if nil).
+	// Durations of operations (e.g. latency metrics and garbage collection
+	// duration) are always measured by real time.
+	Clock Clock
+}
+
+// TierConfig describes one shard section (tier). Records up to MaxSize bytes
+// are stored in the tier (if they do not fit to lower tier) and the tier can
+// allocate up to MaxShards shards.
+type TierConfig struct {
+	MaxSize   uint32
+	MaxShards uint32
+}
+
+// tiers returns list of tiers sorted by record size. If custom tiers are not
+// set, then small, medium and large tiers are returned.
+func (o *Options) tiers() []TierConfig {
+	if len(o.CustomTiers) == 0 {
+		return []TierConfig{
+			{MaxSize: o.RecordSizeSmall, MaxShards: o.MaxShardsSmall},
+			{MaxSize: o.RecordSizeMedium, MaxShards: o.MaxShardsMedium},
+			{MaxSize: o.RecordSizeLarge, MaxShards: o.MaxShardsLarge},
+		}
+	}
+
+	tiers := append([]TierConfig(nil), o.CustomTiers...)
+	sort.Slice(tiers, func(i, j int) bool {
+		return tiers[i].MaxSize < tiers[j].MaxSize
+	})
+
+	return tiers
+}
+
+// validate checks if options are valid. If not, then error is returned.
+func (o *Options) validate() error {
+	tiers := o.tiers()
+	if len(tiers) > math.MaxUint8 {
+		return ErrTiers
+	}
+
+	for i, tier := range tiers {
+		if tier.MaxShards == 0 {
+			return ErrTiers
+		}
+		if i > 0 && tiers[i-1].MaxSize >= tier.MaxSize {
+			return ErrRecordSizeOrder
+		}
+	}
+
+	if tiers[0].MaxSize < MinRecordSize {
+		return ErrRecordSizeMin
+	}
+	if tiers[len(tiers)-1].MaxSize > MaxRecordSize || o.MaxItemSize > MaxRecordSize {
+		return ErrRecordSizeMax
+	}
+	if o.MaxItemSize > tiers[len(tiers)-1].MaxSize && len(tiers) == math.MaxUint8 {
+		return ErrTiers
+	}
+
+	if o.BTreeDegree != 0 && o.BTreeDegree < 3 {
+		return ErrBTreeDegree
+	}
+
+	return nil
 }
 
 // Option specification for Printer package.
@@ -78,3 +309,278 @@ func OptionGcStarter(option uint32) Option {
 		opts.GcStarter = option
 	}
 }
+
+// OptionLogger option specification.
+func OptionLogger(option *slog.Logger) Option {
+	return func(opts *Options) {
+		opts.Logger = option
+	}
+}
+
+// OptionLookupBackend option specification.
+func OptionLookupBackend(option LookupBackend) Option {
+	return func(opts *Options) {
+		opts.LookupBackend = option
+	}
+}
+
+// OptionSlidingExpiration option specification.
+func OptionSlidingExpiration(option bool) Option {
+	return func(opts *Options) {
+		opts.SlidingExpiration = option
+	}
+}
+
+// OptionExpirationJitter option specification. The jitter is generated by
+// math/rand and it is not cryptographically secure.
+func OptionExpirationJitter(option time.Duration) Option {
+	return func(opts *Options) {
+		opts.ExpirationJitter = option
+	}
+}
+
+// OptionDefaultTTL option specification.
+func OptionDefaultTTL(option time.Duration) Option {
+	return func(opts *Options) {
+		opts.DefaultTTL = option
+	}
+}
+
+// OptionLoader option specification.
+func OptionLoader(option func(ctx context.Context, key []byte) ([]byte, time.Duration, error)) Option {
+	return func(opts *Options) {
+		opts.Loader = option
+	}
+}
+
+// OptionStore option specification.
+func OptionStore(option func(ctx context.Context, key, value []byte) error) Option {
+	return func(opts *Options) {
+		opts.Store = option
+	}
+}
+
+// OptionOnStoreError option specification.
+func OptionOnStoreError(option func(key []byte, err error)) Option {
+	return func(opts *Options) {
+		opts.OnStoreError = option
+	}
+}
+
+// OptionCustomTiers option specification.
+func OptionCustomTiers(option []TierConfig) Option {
+	return func(opts *Options) {
+		opts.CustomTiers = option
+	}
+}
+
+// OptionMinKeyLength option specification.
+func OptionMinKeyLength(option int) Option {
+	return func(opts *Options) {
+		opts.MinKeyLength = option
+	}
+}
+
+// OptionMaxKeyLength option specification.
+func OptionMaxKeyLength(option int) Option {
+	return func(opts *Options) {
+		opts.MaxKeyLength = option
+	}
+}
+
+// OptionValueValidator option specification.
+func OptionValueValidator(option func(key, value []byte) error) Option {
+	return func(opts *Options) {
+		opts.ValueValidator = option
+	}
+}
+
+// OptionEvictionPolicy option specification.
+func OptionEvictionPolicy(option EvictionPolicy) Option {
+	return func(opts *Options) {
+		opts.EvictionPolicy = option
+	}
+}
+
+// OptionCircularBuffer option specification.
+func OptionCircularBuffer(option bool) Option {
+	return func(opts *Options) {
+		opts.CircularBuffer = option
+	}
+}
+
+// OptionOnBufferFull option specification.
+func OptionOnBufferFull(option func(dropped BufferItem)) Option {
+	return func(opts *Options) {
+		opts.OnBufferFull = option
+	}
+}
+
+// OptionChecksums option specification.
+func OptionChecksums(option bool) Option {
+	return func(opts *Options) {
+		opts.Checksums = option
+	}
+}
+
+// OptionPool option specification.
+func OptionPool(option *SharedPool) Option {
+	return func(opts *Options) {
+		opts.Pool = option
+	}
+}
+
+// OptionMetrics option specification.
+func OptionMetrics(option MetricsRecorder) Option {
+	return func(opts *Options) {
+		opts.Metrics = option
+	}
+}
+
+// OptionEagerAllocation option specification. Memory cost is
+// maxShards * maxRecords * recordSize bytes per section.
+func OptionEagerAllocation(option bool) Option {
+	return func(opts *Options) {
+		opts.EagerAllocation = option
+	}
+}
+
+// OptionGcUtilizationThreshold option specification.
+func OptionGcUtilizationThreshold(option float64) Option {
+	return func(opts *Options) {
+		opts.GcUtilizationThreshold = option
+	}
+}
+
+// OptionAtomicCounters option specification.
+func OptionAtomicCounters(option uint32) Option {
+	return func(opts *Options) {
+		opts.AtomicCounters = option
+	}
+}
+
+// OptionDebugTrace option specification.
+func OptionDebugTrace(option io.Writer) Option {
+	return func(opts *Options) {
+		opts.DebugTrace = option
+	}
+}
+
+// OptionBTreeDegree option specification.
+func OptionBTreeDegree(option int) Option {
+	return func(opts *Options) {
+		opts.BTreeDegree = option
+	}
+}
+
+// OptionVersion option specification.
+func OptionVersion(option uint32) Option {
+	return func(opts *Options) {
+		opts.Version = option
+	}
+}
+
+// OptionExpirationNotify option specification.
+func OptionExpirationNotify(option chan<- []byte) Option {
+	return func(opts *Options) {
+		opts.ExpirationNotify = option
+	}
+}
+
+// OptionAdaptiveGC option specification.
+func OptionAdaptiveGC(option bool) Option {
+	return func(opts *Options) {
+		opts.AdaptiveGC = option
+	}
+}
+
+// OptionEventBus option specification.
+func OptionEventBus(option EventBus) Option {
+	return func(opts *Options) {
+		opts.EventBus = option
+	}
+}
+
+// OptionHashKeys option specification.
+func OptionHashKeys(option bool) Option {
+	return func(opts *Options) {
+		opts.HashKeys = option
+	}
+}
+
+// OptionKeySalt option specification.
+func OptionKeySalt(option []byte) Option {
+	return func(opts *Options) {
+		opts.KeySalt = option
+	}
+}
+
+// OptionL2Cache option specification.
+func OptionL2Cache(option Cache) Option {
+	return func(opts *Options) {
+		opts.L2Cache = option
+	}
+}
+
+// OptionMaxBufferSize option specification.
+func OptionMaxBufferSize(option uint32) Option {
+	return func(opts *Options) {
+		opts.MaxBufferSize = option
+	}
+}
+
+// OptionMaxItemSize option specification.
+func OptionMaxItemSize(option uint32) Option {
+	return func(opts *Options) {
+		opts.MaxItemSize = option
+	}
+}
+
+// OptionTierPromotion option specification.
+func OptionTierPromotion(option bool) Option {
+	return func(opts *Options) {
+		opts.TierPromotion = option
+	}
+}
+
+// OptionTierPromotionThreshold option specification.
+func OptionTierPromotionThreshold(option uint32) Option {
+	return func(opts *Options) {
+		opts.TierPromotionThreshold = option
+	}
+}
+
+// OptionHashSeed option specification.
+func OptionHashSeed(option uint64) Option {
+	return func(opts *Options) {
+		opts.HashSeed = option
+	}
+}
+
+// OptionClock option specification.
+func OptionClock(option Clock) Option {
+	return func(opts *Options) {
+		opts.Clock = option
+	}
+}
+
+// OptionPrefetchThreshold option specification.
+func OptionPrefetchThreshold(option float64) Option {
+	return func(opts *Options) {
+		opts.PrefetchThreshold = option
+	}
+}
+
+// OptionPrefetchInterval option specification.
+func OptionPrefetchInterval(option time.Duration) Option {
+	return func(opts *Options) {
+		opts.PrefetchInterval = option
+	}
+}
+
+// OptionReadOnly option specification.
+func OptionReadOnly(option bool) Option {
+	return func(opts *Options) {
+		opts.ReadOnly = option
+	}
+}
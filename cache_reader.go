@@ -0,0 +1,55 @@
+package atomiccache
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// readerBuffers is pool of buffers used by SetFromReader, so data can be read
+// before the cache lock is acquired without allocation on every call.
+var readerBuffers = sync.Pool{New: func() any { return new([]byte) }}
+
+// maxReaderBufferSize is maximum size of record read to buffer of the pool.
+// Buffer of larger record is allocated for the call only, so a few large
+// records do not pin memory of maximum item size in the pool.
+const maxReaderBufferSize = 64 * 1024
+
+// SetFromReader store exactly size bytes from reader to cache memory. The size
+// has to be known in advance, ErrDataLimit is returned if it is greater than
+// maximum item size. Data are read to buffer (taken from pool up to 64 KiB)
+// before the cache lock is acquired, so slow reader (e.g. HTTP request body)
+// does not block other operations, and copied to free slot afterwards.
+// Record is stored same way as by SetNoStore (write-through store is
+// bypassed). If reading fails, nothing is stored and the error is returned.
+func (a *AtomicCache) SetFromReader(key []byte, r io.Reader, size int, expire time.Duration) error {
+	if err := a.checkKey(key); err != nil {
+		return err
+	}
+
+	if size < 0 || size > int(a.maxItemSize) {
+		return ErrDataLimit
+	}
+
+	if err := a.checkWritable(); err != nil {
+		return err
+	}
+
+	var data []byte
+	if size > maxReaderBufferSize {
+		data = make([]byte, size)
+	} else {
+		buf := readerBuffers.Get().(*[]byte)
+		defer readerBuffers.Put(buf)
+
+		if cap(*buf) < size {
+			*buf = make([]byte, size)
+		}
+		data = (*buf)[:size]
+	}
+	if _, err := io.ReadFull(r, data); err != nil {
+		return err
+	}
+
+	return a.SetNoStore(key, data, expire)
+}
@@ -0,0 +1,95 @@
+package atomiccache
+
+import (
+	"bytes"
+	"io"
+	"reflect"
+	"testing"
+)
+
+func TestCacheSetFromReader(t *testing.T) {
+	cache := New(OptionRecordSizeSmall(8), OptionRecordSizeMedium(16), OptionRecordSizeLarge(32))
+	cache.Set([]byte("key"), []byte("old"), 0)
+
+	data := []byte("medium-data")
+	if err := cache.SetFromReader([]byte("key"), bytes.NewReader(data), len(data), 0); err != nil {
+		t.Errorf("SetFromReader error: %s", err.Error())
+	}
+	if result, err := cache.Get([]byte("key")); err != nil || !reflect.DeepEqual(result, data) {
+		t.Errorf("%q != %q", result, data)
+	}
+	if section, _ := cache.Type([]byte("key")); section != "medium" {
+		t.Errorf("%v != %v", section, "medium")
+	}
+
+	if err := cache.SetFromReader([]byte("short"), bytes.NewReader(data), 12, 0); err != io.ErrUnexpectedEOF {
+		t.Errorf("Expecting error 'ErrUnexpectedEOF'")
+	}
+	if cache.Exists([]byte("short")) {
+		t.Errorf("Record of failed read is stored")
+	}
+
+	if err := cache.SetFromReader([]byte("large"), bytes.NewReader(data), 33, 0); err != ErrDataLimit {
+		t.Errorf("Expecting error 'ErrDataLimit'")
+	}
+}
+
+func TestCacheSetFromReaderLarge(t *testing.T) {
+	cache := New(OptionRecordSizeLarge(2 * maxReaderBufferSize))
+
+	data := bytes.Repeat([]byte("x"), maxReaderBufferSize+1)
+	if err := cache.SetFromReader([]byte("key"), bytes.NewReader(data), len(data), 0); err != nil {
+		t.Errorf("SetFromReader error: %s", err.Error())
+	}
+	if result, err := cache.Get([]byte("key")); err != nil || !bytes.Equal(result, data) {
+		t.Errorf("%v != %v", len(result), len(data))
+	}
+
+	buf := readerBuffers.Get().(*[]byte)
+	if cap(*buf) > maxReaderBufferSize {
+		t.Errorf("Buffer of %v bytes is kept in pool", cap(*buf))
+	}
+	readerBuffers.Put(buf)
+}
+
+// blockingReader blocks until release channel is closed.
+type blockingReader struct {
+	started chan struct{}
+	release chan struct{}
+}
+
+func (r *blockingReader) Read(p []byte) (int, error) {
+	close(r.started)
+	<-r.release
+	return copy(p, "data"), io.EOF
+}
+
+func TestCacheSetFromReaderUnlocked(t *testing.T) {
+	cache := New(OptionAtomicCounters(1))
+	cache.Set([]byte("key"), []byte("1"), 0)
+	events, cancel := cache.Watch([]byte("key"))
+	defer cancel()
+
+	r := &blockingReader{started: make(chan struct{}), release: make(chan struct{})}
+	done := make(chan error)
+	go func() {
+		done <- cache.SetFromReader([]byte("key"), r, 4, 0)
+	}()
+	<-r.started
+
+	// Cache is not locked while reader blocks
+	if data, err := cache.Get([]byte("key")); err != nil || string(data) != "1" {
+		t.Errorf("%q != %q", data, "1")
+	}
+	close(r.release)
+
+	if err := <-done; err != nil {
+		t.Errorf("SetFromReader error: %s", err.Error())
+	}
+	if data, err := cache.Get([]byte("key")); err != nil || string(data) != "data" {
+		t.Errorf("%q != %q", data, "data")
+	}
+	if event := <-events; event.Type != EventSet || string(event.Data) != "data" {
+		t.Errorf("%v != %v", event, EventSet)
+	}
+}
@@ -0,0 +1,337 @@
+package atomiccache
+
+import (
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// GcDurationBuckets are upper bounds of garbage collection duration histogram
+// reported in Stats.
+var GcDurationBuckets = [...]time.Duration{
+	100 * time.Microsecond,
+	time.Millisecond,
+	10 * time.Millisecond,
+	100 * time.Millisecond,
+	time.Second,
+}
+
+// Stats is a point-in-time snapshot of cache memory statistics.
+type Stats struct {
+	// Number of successful Get calls.
+	Hits uint64
+	// Number of Get calls which ended up with ErrNotFound.
+	Misses uint64
+	// Number of records removed by garbage collector (expired or invalidated
+	// by Bump) and by eviction policy.
+	Evictions uint64
+	// Number of records in lookup table.
+	Items int
+	// Number of bytes allocated by active shards (all shards if eager
+	// allocation is enabled).
+	MemoryBytesUsed uint64
+	// Number of unattended set requests in buffer.
+	BufferLen int
+	// Number of finished garbage collections.
+	GcRuns uint64
+	// Total duration of all finished garbage collections.
+	GcDurationSum time.Duration
+	// Cumulative number of garbage collections which took less or equal time
+	// than the bucket upper bound (see GcDurationBuckets).
+	GcDurationBuckets map[time.Duration]uint64
+	// True if missing records are loaded by loader.
+	LoaderEnabled bool
+	// Start of the period in which counters (hits, misses, evictions and
+	// garbage collections) were accumulated.
+	Since time.Time
+}
+
+// HitRate returns ratio of hits to all Get calls. If there was no Get call,
+// then 0 is returned.
+func (s Stats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// maxStatsCheckpoints is maximum number of stored statistics checkpoints.
+const maxStatsCheckpoints = 256
+
+// statsSnapshot contains values of statistics counters at specified time.
+type statsSnapshot struct {
+	at                time.Time
+	hits              uint64
+	misses            uint64
+	evictions         uint64
+	gcRuns            uint64
+	gcDurationSum     uint64
+	gcDurationBuckets [len(GcDurationBuckets)]uint64
+}
+
+// statsCounters contains atomic counters used for Stats snapshot.
+type statsCounters struct {
+	hits              atomic.Uint64
+	misses            atomic.Uint64
+	evictions         atomic.Uint64
+	gcRuns            atomic.Uint64
+	gcDurationSum     atomic.Uint64
+	gcDurationBuckets [len(GcDurationBuckets)]atomic.Uint64
+	lastGcDuration    atomic.Int64
+	maxGcDuration     atomic.Int64
+}
+
+// Stats returns snapshot of cache memory statistics. The snapshot is a copy,
+// so no lock is held after the function returns. Counters are accumulated
+// since creation of cache or since the last StatsReset.
+func (a *AtomicCache) Stats() Stats {
+	a.statsMutex.Lock()
+	base := a.statsBase
+	a.statsMutex.Unlock()
+
+	return a.statsFrom(base)
+}
+
+// StatsReset resets statistics counters, so following Stats contain only
+// values accumulated since the reset (e.g. hit rate of recent period).
+func (a *AtomicCache) StatsReset() {
+	a.statsMutex.Lock()
+	a.statsBase = a.stats.snapshot(a.clock.Now())
+	a.addStatsCheckpointLocked(a.statsBase)
+	a.statsMutex.Unlock()
+}
+
+// StatsSince returns statistics accumulated since specified time. Counters are
+// not tracked continuously, checkpoints are taken after every garbage
+// collection and on StatsReset (up to 256 latest ones), so the statistics are
+// computed from the latest checkpoint which is not after the time (or from the
+// oldest one). Start of the period is returned in Since field.
+func (a *AtomicCache) StatsSince(t time.Time) Stats {
+	var base statsSnapshot
+
+	a.statsMutex.Lock()
+	if len(a.statsCheckpoints) > 0 {
+		base = a.statsCheckpoints[0]
+	}
+	for _, checkpoint := range a.statsCheckpoints {
+		if checkpoint.at.After(t) {
+			break
+		}
+		base = checkpoint
+	}
+	a.statsMutex.Unlock()
+
+	return a.statsFrom(base)
+}
+
+// recordStatsCheckpoint stores current values of statistics counters as new
+// checkpoint.
+func (a *AtomicCache) recordStatsCheckpoint() {
+	a.statsMutex.Lock()
+	a.addStatsCheckpointLocked(a.stats.snapshot(a.clock.Now()))
+	a.statsMutex.Unlock()
+}
+
+// addStatsCheckpointLocked appends checkpoint and removes the oldest one if
+// there are too many checkpoints.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) addStatsCheckpointLocked(checkpoint statsSnapshot) {
+	if len(a.statsCheckpoints) == maxStatsCheckpoints {
+		a.statsCheckpoints = append(a.statsCheckpoints[:0], a.statsCheckpoints[1:]...)
+	}
+	a.statsCheckpoints = append(a.statsCheckpoints, checkpoint)
+}
+
+// statsFrom returns snapshot of cache memory statistics with counters
+// accumulated since base snapshot.
+func (a *AtomicCache) statsFrom(base statsSnapshot) Stats {
+	current := a.stats.snapshot(a.clock.Now())
+	stats := Stats{
+		Hits:              current.hits - base.hits,
+		Misses:            current.misses - base.misses,
+		Evictions:         current.evictions - base.evictions,
+		GcRuns:            current.gcRuns - base.gcRuns,
+		GcDurationSum:     time.Duration(current.gcDurationSum - base.gcDurationSum),
+		GcDurationBuckets: make(map[time.Duration]uint64, len(GcDurationBuckets)),
+		LoaderEnabled:     a.loader != nil,
+		Since:             base.at,
+	}
+
+	for i, bound := range GcDurationBuckets {
+		stats.GcDurationBuckets[bound] = current.gcDurationBuckets[i] - base.gcDurationBuckets[i]
+	}
+
+	a.RLock()
+	stats.Items = a.lookup.Size() + len(a.counterIndex)
+	stats.BufferLen = a.buffer.len()
+	stats.MemoryBytesUsed = a.allocatedBytesLocked()
+	a.RUnlock()
+
+	return stats
+}
+
+// snapshot returns current values of counters taken at specified time.
+func (s *statsCounters) snapshot(now time.Time) statsSnapshot {
+	snapshot := statsSnapshot{
+		at:            now,
+		hits:          s.hits.Load(),
+		misses:        s.misses.Load(),
+		evictions:     s.evictions.Load(),
+		gcRuns:        s.gcRuns.Load(),
+		gcDurationSum: s.gcDurationSum.Load(),
+	}
+	for i := range s.gcDurationBuckets {
+		snapshot.gcDurationBuckets[i] = s.gcDurationBuckets[i].Load()
+	}
+
+	return snapshot
+}
+
+// recordGcDuration updates garbage collection counters.
+func (s *statsCounters) recordGcDuration(duration time.Duration) {
+	s.gcRuns.Add(1)
+	s.gcDurationSum.Add(uint64(duration))
+	s.lastGcDuration.Store(int64(duration))
+	for longest := s.maxGcDuration.Load(); int64(duration) > longest; longest = s.maxGcDuration.Load() {
+		if s.maxGcDuration.CompareAndSwap(longest, int64(duration)) {
+			break
+		}
+	}
+	for i, bound := range GcDurationBuckets {
+		if duration <= bound {
+			s.gcDurationBuckets[i].Add(1)
+		}
+	}
+}
+
+// LastGCDuration returns duration of the last garbage collection. Garbage
+// collection holds the write lock, so it is also length of the last pause of
+// all cache operations. Zero is returned if no garbage collection finished.
+func (a *AtomicCache) LastGCDuration() time.Duration {
+	return time.Duration(a.stats.lastGcDuration.Load())
+}
+
+// MaxGCDuration returns duration of the longest garbage collection.
+func (a *AtomicCache) MaxGCDuration() time.Duration {
+	return time.Duration(a.stats.maxGcDuration.Load())
+}
+
+// StoredBytes returns sum of lengths of all valid records. Data are not
+// copied, only lengths of records are read under read lock.
+func (a *AtomicCache) StoredBytes() uint64 {
+	var result uint64
+
+	now := a.clock.Now()
+
+	a.RLock()
+	for _, key := range a.lookupKeysLocked() {
+		val, ok := a.lookup.Get(key)
+		if !ok || !a.isValidRecord(val, now) {
+			continue
+		}
+
+		shardSection := a.getShardsSectionByID(val.ShardSection)
+		if shard := shardSection.shards[val.ShardIndex]; shard != nil {
+			length := shard.slots[val.RecordIndex].Len()
+			if a.checksums && length >= checksumSize {
+				length -= checksumSize
+			}
+			result += uint64(length)
+		}
+	}
+	a.RUnlock()
+
+	return result
+}
+
+// AllocatedBytes returns number of bytes allocated by shards of all sections
+// (all shards if eager allocation is enabled). Slots are not examined, so it
+// is cheap enough to be called frequently.
+func (a *AtomicCache) AllocatedBytes() uint64 {
+	a.RLock()
+	defer a.RUnlock()
+
+	return a.allocatedBytesLocked()
+}
+
+// allocatedBytesLocked returns number of bytes allocated by shards.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) allocatedBytesLocked() uint64 {
+	var result uint64
+
+	for _, shardSection := range a.sections {
+		for _, shard := range shardSection.shards {
+			if shard != nil {
+				result += uint64(shardSection.slotCount) * uint64(shardSection.recordSize)
+			}
+		}
+	}
+
+	return result
+}
+
+// BTreeNodeOverhead is estimated number of bytes used by lookup table per key
+// (except the key itself). It includes lookup record and node bookkeeping and
+// it can be adjusted according to lookup backend and BTree degree.
+var BTreeNodeOverhead = uint64(unsafe.Sizeof(LookupRecord{})) + 32
+
+// EstimatedTotalBytes returns estimated number of bytes attributed to cache
+// memory. It is sum of shard memory (see AllocatedBytes), lookup table memory
+// (keys and BTreeNodeOverhead per key), buffer of unattended set requests,
+// counters and watchers registry. All keys are examined under read lock.
+func (a *AtomicCache) EstimatedTotalBytes() uint64 {
+	a.RLock()
+	result := a.allocatedBytesLocked()
+
+	keys := a.lookup.Keys()
+	for _, key := range keys {
+		result += uint64(len(key))
+	}
+	result += uint64(len(keys)) * BTreeNodeOverhead
+
+	result += uint64(cap(a.buffer.items)) * uint64(unsafe.Sizeof(BufferItem{}))
+	for _, item := range a.buffer.items {
+		result += uint64(len(item.Key) + len(item.Data))
+	}
+
+	result += uint64(len(a.counters)) * uint64(unsafe.Sizeof(atomic.Int64{}))
+	for key := range a.counterIndex {
+		result += uint64(len(key)) + uint64(unsafe.Sizeof(counterRecord{}))
+	}
+	a.RUnlock()
+
+	a.watchMutex.Lock()
+	for key, watchers := range a.watchers {
+		result += uint64(len(key)) + uint64(len(watchers))*watchBufferSize*uint64(unsafe.Sizeof(WatchEvent{}))
+	}
+	a.watchMutex.Unlock()
+
+	return result
+}
+
+// LiveBytes returns sum of lengths of data stored in all used slots. Unlike
+// StoredBytes, expired records which were not collected yet are included.
+// Every slot of allocated shards is examined under read lock.
+func (a *AtomicCache) LiveBytes() uint64 {
+	var result uint64
+
+	a.RLock()
+	for _, shardSection := range a.sections {
+		for _, shard := range shardSection.shards {
+			if shard == nil {
+				continue
+			}
+			for _, slot := range shard.slots {
+				length := slot.Len()
+				if a.checksums && length >= checksumSize {
+					length -= checksumSize
+				}
+				result += uint64(length)
+			}
+		}
+	}
+	a.RUnlock()
+
+	return result
+}
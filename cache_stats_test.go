@@ -0,0 +1,205 @@
+package atomiccache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestCacheStats(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("data"), 0)
+	cache.Set([]byte("expired"), []byte("data"), 10*time.Millisecond)
+	cache.Get([]byte("key"))
+	cache.Get([]byte("missing"))
+	time.Sleep(20 * time.Millisecond)
+	cache.collectGarbage()
+
+	stats := cache.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("Unexpected hits/misses: %d/%d", stats.Hits, stats.Misses)
+	}
+	if stats.Evictions != 1 || stats.Items != 1 {
+		t.Errorf("Unexpected evictions/items: %d/%d", stats.Evictions, stats.Items)
+	}
+	if stats.GcRuns != 1 || stats.GcDurationBuckets[time.Second] != 1 {
+		t.Errorf("Unexpected garbage collection stats: %+v", stats)
+	}
+	if want := uint64(cache.MaxRecords) * uint64(cache.RecordSizeSmall+cache.RecordSizeMedium+cache.RecordSizeLarge); stats.MemoryBytesUsed != want {
+		t.Errorf("%v != %v", stats.MemoryBytesUsed, want)
+	}
+}
+
+func TestCacheStoredBytes(t *testing.T) {
+	for _, checksums := range []bool{false, true} {
+		cache := New(OptionChecksums(checksums))
+		cache.Set([]byte("key1"), make([]byte, 10), 0)
+		cache.Set([]byte("key2"), make([]byte, 1000), 0)
+		cache.Set([]byte("expired"), make([]byte, 100), time.Nanosecond)
+		time.Sleep(time.Millisecond)
+
+		if size := cache.StoredBytes(); size != 1010 {
+			t.Errorf("[%v] %v != %v", checksums, size, 1010)
+		}
+	}
+}
+
+func TestCacheStoredBytesClock(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	cache := New(OptionClock(clock))
+	cache.Set([]byte("key"), make([]byte, 10), 0)
+	cache.Set([]byte("expiring"), make([]byte, 100), time.Minute)
+
+	if size := cache.StoredBytes(); size != 110 {
+		t.Errorf("%v != %v", size, 110)
+	}
+
+	clock.Advance(2 * time.Minute)
+	if size := cache.StoredBytes(); size != 10 {
+		t.Errorf("%v != %v", size, 10)
+	}
+}
+
+func TestCacheAllocatedBytes(t *testing.T) {
+	cache := New(OptionMaxRecords(2))
+	want := uint64(cache.MaxRecords) * uint64(cache.RecordSizeSmall+cache.RecordSizeMedium+cache.RecordSizeLarge)
+	if size := cache.AllocatedBytes(); size != want {
+		t.Errorf("%v != %v", size, want)
+	}
+
+	for i := 0; i < 3; i++ {
+		cache.Set([]byte{'k', byte(i)}, []byte("data"), 0)
+	}
+	want += uint64(cache.MaxRecords) * uint64(cache.RecordSizeSmall)
+	if size := cache.AllocatedBytes(); size != want {
+		t.Errorf("%v != %v", size, want)
+	}
+}
+
+func TestCacheLiveBytes(t *testing.T) {
+	cache := New(OptionChecksums(true))
+	cache.Set([]byte("key1"), make([]byte, 10), 0)
+	cache.Set([]byte("key2"), make([]byte, 1000), 0)
+	cache.Set([]byte("expired"), make([]byte, 100), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	if size := cache.LiveBytes(); size != 1110 {
+		t.Errorf("%v != %v", size, 1110)
+	}
+
+	cache.CollectGarbage()
+	if size := cache.LiveBytes(); size != 1010 {
+		t.Errorf("%v != %v", size, 1010)
+	}
+}
+
+func TestCacheGCDuration(t *testing.T) {
+	cache := New()
+	if cache.LastGCDuration() != 0 || cache.MaxGCDuration() != 0 {
+		t.Errorf("Unexpected durations before garbage collection")
+	}
+
+	cache.stats.recordGcDuration(2 * time.Millisecond)
+	cache.stats.recordGcDuration(time.Millisecond)
+
+	if duration := cache.LastGCDuration(); duration != time.Millisecond {
+		t.Errorf("%v != %v", duration, time.Millisecond)
+	}
+	if duration := cache.MaxGCDuration(); duration != 2*time.Millisecond {
+		t.Errorf("%v != %v", duration, 2*time.Millisecond)
+	}
+}
+
+func TestCacheGCDurationFakeClock(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	cache := New(OptionClock(clock))
+	for i := 0; i < 1000; i++ {
+		cache.Set([]byte(strconv.Itoa(i)), []byte("data"), time.Second)
+	}
+	clock.Advance(2 * time.Second)
+	cache.CollectGarbage()
+
+	if duration := cache.LastGCDuration(); duration <= 0 {
+		t.Errorf("Garbage collection duration is not measured by real time")
+	}
+}
+
+func TestCacheStatsHitRate(t *testing.T) {
+	cache := New()
+	if rate := cache.Stats().HitRate(); rate != 0 {
+		t.Errorf("%v != %v", rate, 0)
+	}
+
+	cache.Set([]byte("key"), []byte("data"), 0)
+	cache.Get([]byte("key"))
+	cache.Get([]byte("key"))
+	cache.Get([]byte("key"))
+	cache.Get([]byte("missing"))
+
+	if rate := cache.Stats().HitRate(); rate != 0.75 {
+		t.Errorf("%v != %v", rate, 0.75)
+	}
+}
+
+func TestCacheStatsReset(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("data"), 0)
+	cache.Get([]byte("key"))
+	cache.Get([]byte("missing"))
+
+	before := time.Now()
+	cache.StatsReset()
+
+	stats := cache.Stats()
+	if stats.Hits != 0 || stats.Misses != 0 {
+		t.Errorf("%v, %v != 0, 0", stats.Hits, stats.Misses)
+	}
+	if stats.Since.Before(before) {
+		t.Errorf("%v is before %v", stats.Since, before)
+	}
+
+	cache.Get([]byte("key"))
+	if stats := cache.Stats(); stats.Hits != 1 || stats.HitRate() != 1 {
+		t.Errorf("%v, %v != 1, 1", stats.Hits, stats.HitRate())
+	}
+}
+
+func TestCacheStatsSince(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("data"), 0)
+	cache.Get([]byte("key"))
+
+	checkpoint := time.Now()
+	time.Sleep(time.Millisecond)
+	cache.CollectGarbage()
+	cache.Get([]byte("missing"))
+
+	if stats := cache.StatsSince(time.Now()); stats.Hits != 0 || stats.Misses != 1 {
+		t.Errorf("%v, %v != 0, 1", stats.Hits, stats.Misses)
+	}
+	if stats := cache.StatsSince(checkpoint); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("%v, %v != 1, 1", stats.Hits, stats.Misses)
+	}
+	if stats := cache.StatsSince(time.Time{}); stats.Hits != 1 || stats.Misses != 1 {
+		t.Errorf("%v, %v != 1, 1", stats.Hits, stats.Misses)
+	}
+}
+
+func TestCacheEstimatedTotalBytes(t *testing.T) {
+	cache := New()
+	empty := cache.EstimatedTotalBytes()
+	if empty != cache.AllocatedBytes() {
+		t.Errorf("%v != %v", empty, cache.AllocatedBytes())
+	}
+
+	cache.Set([]byte("key"), []byte("data"), 0)
+	if size := cache.EstimatedTotalBytes(); size != empty+3+BTreeNodeOverhead {
+		t.Errorf("%v != %v", size, empty+3+BTreeNodeOverhead)
+	}
+
+	_, stop := cache.Watch([]byte("key"))
+	defer stop()
+	if size := cache.EstimatedTotalBytes(); size <= empty+3+BTreeNodeOverhead {
+		t.Errorf("Watchers are not included")
+	}
+}
@@ -0,0 +1,94 @@
+package atomiccache
+
+import (
+	"context"
+	"time"
+)
+
+// SetWithTags store data to cache memory same way as Set and in addition it
+// registers the key for every tag on input. Record is stored and tagged under
+// the same lock. All keys registered for one tag can be removed at once by
+// DeleteByTag. Tags of the key are removed when its record is removed,
+// overwritten (e.g. by Set, Update or Swap) or expired, so they have to be set
+// again by SetWithTags. If record was not stored to cache
+// memory (e.g. it was stored to buffer), tags are not registered.
+func (a *AtomicCache) SetWithTags(key, data []byte, expire time.Duration, tags []string) error {
+	if err := a.setNoStore(key, data, expire, a.getExprTime(expire), tags); err != nil {
+		return err
+	}
+
+	a.persist(context.Background(), key, data, expire)
+
+	return nil
+}
+
+// DeleteByTag removes all records registered for the tag. Removed records are
+// removed from their other tags too. It returns number of successfully deleted
+// records. If tag is not known, then error is returned.
+func (a *AtomicCache) DeleteByTag(tag string) (int, error) {
+	if err := a.checkWritable(); err != nil {
+		return 0, err
+	}
+
+	a.RLock()
+	keys := make([]string, 0, len(a.tags[tag]))
+	for key := range a.tags[tag] {
+		keys = append(keys, key)
+	}
+	_, ok := a.tags[tag]
+	a.RUnlock()
+
+	if !ok {
+		return 0, ErrNotFound
+	}
+
+	deleted := 0
+	for _, key := range keys {
+		if err := a.Delete([]byte(key)); err == nil {
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// tagLocked registers the key for every tag on input.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) tagLocked(key string, tags []string) {
+	if len(tags) == 0 {
+		return
+	}
+
+	keyTags, ok := a.keyTags[key]
+	if !ok {
+		keyTags = make(map[string]struct{}, len(tags))
+		a.keyTags[key] = keyTags
+	}
+
+	for _, tag := range tags {
+		keys, ok := a.tags[tag]
+		if !ok {
+			keys = make(map[string]struct{})
+			a.tags[tag] = keys
+		}
+		keys[key] = struct{}{}
+		keyTags[tag] = struct{}{}
+	}
+}
+
+// untagLocked removes the key from tags index. Tags without keys are removed.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) untagLocked(key string) {
+	keyTags, ok := a.keyTags[key]
+	if !ok {
+		return
+	}
+
+	delete(a.keyTags, key)
+	for tag := range keyTags {
+		delete(a.tags[tag], key)
+		if len(a.tags[tag]) == 0 {
+			delete(a.tags, tag)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package atomiccache
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCacheDeleteByTag(t *testing.T) {
+	cache := New()
+	cache.SetWithTags([]byte("user:1:profile"), []byte("data"), 0, []string{"user:1"})
+	cache.SetWithTags([]byte("user:1:posts"), []byte("data"), 0, []string{"user:1", "posts"})
+	cache.SetWithTags([]byte("user:2:profile"), []byte("data"), 0, []string{"user:2"})
+
+	deleted, err := cache.DeleteByTag("user:1")
+	if err != nil {
+		t.Errorf("DeleteByTag error: %s", err.Error())
+	}
+	if deleted != 2 {
+		t.Errorf("%v != %v", deleted, 2)
+	}
+
+	if _, err := cache.Get([]byte("user:1:posts")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+	if _, err := cache.Get([]byte("user:2:profile")); err != nil {
+		t.Errorf("Get error: %s", err.Error())
+	}
+
+	if _, err := cache.DeleteByTag("user:1"); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestCacheTagsPruning(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	cache := New(OptionClock(clock))
+	cache.SetWithTags([]byte("deleted"), []byte("data"), 0, []string{"a", "b"})
+	cache.SetWithTags([]byte("replaced"), []byte("data"), 0, []string{"a"})
+	cache.SetWithTags([]byte("updated"), []byte("data"), 0, []string{"a"})
+	cache.SetWithTags([]byte("swapped"), []byte("data"), 0, []string{"a"})
+	cache.SetWithTags([]byte("expired"), []byte("data"), time.Second, []string{"c"})
+	cache.SetWithTags([]byte("kept"), []byte("data"), 0, []string{"b", "b"})
+
+	cache.Delete([]byte("deleted"))
+	cache.Set([]byte("replaced"), []byte("new"), 0)
+	cache.Update([]byte("updated"), func(current []byte) ([]byte, error) { return []byte("new"), nil })
+	cache.Swap([]byte("swapped"), []byte("new"), 0)
+	clock.Advance(2 * time.Second)
+	cache.CollectGarbage()
+
+	if !reflect.DeepEqual(cache.tags, map[string]map[string]struct{}{"b": {"kept": {}}}) {
+		t.Errorf("%v != %v", cache.tags, "map[b:map[kept:{}]]")
+	}
+	if !reflect.DeepEqual(cache.keyTags, map[string]map[string]struct{}{"kept": {"b": {}}}) {
+		t.Errorf("%v != %v", cache.keyTags, "map[kept:map[b:{}]]")
+	}
+	if _, err := cache.DeleteByTag("a"); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestCacheDeleteByTagOtherTags(t *testing.T) {
+	cache := New()
+	cache.SetWithTags([]byte("key"), []byte("data"), 0, []string{"a", "b"})
+
+	if deleted, err := cache.DeleteByTag("a"); err != nil || deleted != 1 {
+		t.Errorf("%v != %v", deleted, 1)
+	}
+	if len(cache.tags) != 0 || len(cache.keyTags) != 0 {
+		t.Errorf("Deleted key is kept in tags index: %v", cache.tags)
+	}
+}
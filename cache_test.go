@@ -1,10 +1,15 @@
 package atomiccache
 
 import (
+	"bytes"
+	"context"
 	"encoding/binary"
+	"errors"
+	"log/slog"
 	"math/rand"
 	"reflect"
 	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -117,7 +122,7 @@ func TestCacheDataError(t *testing.T) {
 		in               []byte
 		want             []byte
 	}{
-		{1, 2, 3, []byte{0, 1, 2, 3, 4, 5}, []byte{0}},
+		{8, 16, 32, make([]byte, 64), []byte{0}},
 	} {
 		cache := New(OptionRecordSizeSmall(c.recordSizeSmall), OptionRecordSizeMedium(c.recordSizeMedium), OptionRecordSizeLarge(c.recordSizeLarge))
 		if err := cache.Set([]byte{byte(i)}, c.in, 0); err == nil {
@@ -126,6 +131,85 @@ func TestCacheDataError(t *testing.T) {
 	}
 }
 
+func TestCacheNewWithError(t *testing.T) {
+	for _, c := range []struct {
+		recordSizeSmall  uint32
+		recordSizeMedium uint32
+		recordSizeLarge  uint32
+		want             error
+	}{
+		{8, 16, 32, nil},
+		{16, 16, 32, ErrRecordSizeOrder},
+		{8, 32, 16, ErrRecordSizeOrder},
+		{4, 16, 32, ErrRecordSizeMin},
+		{8, 16, MaxRecordSize + 1, ErrRecordSizeMax},
+	} {
+		_, err := NewWithError(OptionRecordSizeSmall(c.recordSizeSmall), OptionRecordSizeMedium(c.recordSizeMedium), OptionRecordSizeLarge(c.recordSizeLarge), OptionMaxShardsSmall(1), OptionMaxShardsMedium(1), OptionMaxShardsLarge(1), OptionMaxRecords(1))
+		if err != c.want {
+			t.Errorf("%v != %v", err, c.want)
+		}
+	}
+}
+
+func TestCacheCustomTiers(t *testing.T) {
+	cache := New(OptionMaxRecords(16), OptionCustomTiers([]TierConfig{
+		{MaxSize: 1024, MaxShards: 2}, {MaxSize: 16, MaxShards: 2}, {MaxSize: 64, MaxShards: 2}, {MaxSize: 256, MaxShards: 2},
+	}))
+
+	for _, c := range []struct {
+		size int
+		want string
+	}{
+		{9, "tier-1"}, {17, "tier-2"}, {100, "tier-3"}, {1024, "tier-4"},
+	} {
+		if err := cache.Set([]byte("key"), make([]byte, c.size), 0); err != nil {
+			t.Errorf("Set error: %s", err.Error())
+		}
+		if section, err := cache.Type([]byte("key")); err != nil || section != c.want {
+			t.Errorf("%v != %v", section, c.want)
+		}
+	}
+
+	if err := cache.Set([]byte("key"), make([]byte, 1025), 0); !errors.Is(err, ErrDataLimit) {
+		t.Errorf("Expecting error 'ErrDataLimit'")
+	}
+}
+
+func TestCacheKeyLength(t *testing.T) {
+	cache := New(OptionMinKeyLength(2), OptionMaxKeyLength(4))
+	for _, c := range []struct {
+		key  string
+		want error
+	}{
+		{"", ErrKeyTooShort}, {"k", ErrKeyTooShort}, {"ke", nil}, {"keys", nil}, {"keyss", ErrKeyTooLong},
+	} {
+		if err := cache.Set([]byte(c.key), []byte("data"), 0); err != c.want {
+			t.Errorf("%v != %v", err, c.want)
+		}
+	}
+
+	if err := New().Set([]byte{}, []byte("data"), 0); err != ErrKeyTooShort {
+		t.Errorf("Expecting error 'ErrKeyTooShort'")
+	}
+}
+
+func TestCacheValueValidator(t *testing.T) {
+	errEmpty := errors.New("empty value")
+	cache := New(OptionValueValidator(func(key, value []byte) error {
+		if len(value) == 0 {
+			return errEmpty
+		}
+		return nil
+	}))
+
+	if err := cache.Set([]byte("key"), []byte{}, 0); err != errEmpty {
+		t.Errorf("%v != %v", err, errEmpty)
+	}
+	if err := cache.Set([]byte("key"), []byte("data"), 0); err != nil {
+		t.Errorf("Set error: %s", err.Error())
+	}
+}
+
 func TestCacheFreeAfterExpiration(t *testing.T) {
 	cache := New(OptionGcStarter(1))
 
@@ -145,6 +229,563 @@ func TestCacheFreeAfterExpiration(t *testing.T) {
 	}
 }
 
+func TestCacheDelete(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("data"), 0)
+
+	if err := cache.Delete([]byte("key")); err != nil {
+		t.Errorf("Delete error: %s", err.Error())
+	}
+	if _, err := cache.Get([]byte("key")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+	if err := cache.Delete([]byte("key")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestCacheExpire(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("data"), 10*time.Millisecond)
+
+	if err := cache.Expire([]byte("key"), time.Minute); err != nil {
+		t.Errorf("Expire error: %s", err.Error())
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if !cache.Exists([]byte("key")) {
+		t.Errorf("Cache is empty, but expecting some data")
+	}
+	if err := cache.Expire([]byte("missing"), time.Minute); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestCacheTouch(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("data"), 10*time.Millisecond)
+	before, _ := cache.GetMeta([]byte("key"))
+
+	time.Sleep(5 * time.Millisecond)
+	if err := cache.Touch([]byte("key"), time.Minute); err != nil {
+		t.Errorf("Touch error: %s", err.Error())
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	after, err := cache.GetMeta([]byte("key"))
+	if err != nil {
+		t.Errorf("GetMeta error: %s", err.Error())
+	}
+	if !after.LastAccess.After(before.LastAccess) {
+		t.Errorf("Last access time was not updated")
+	}
+	if err := cache.Touch([]byte("missing"), time.Minute); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestCacheSlidingExpiration(t *testing.T) {
+	cache := New(OptionSlidingExpiration(true))
+	cache.Set([]byte("key"), []byte("data"), 50*time.Millisecond)
+
+	for i := 0; i < 4; i++ {
+		time.Sleep(25 * time.Millisecond)
+		if _, err := cache.Get([]byte("key")); err != nil {
+			t.Errorf("Get error: %s", err.Error())
+		}
+	}
+
+	time.Sleep(60 * time.Millisecond)
+	if _, err := cache.Get([]byte("key")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestCacheExpirationJitter(t *testing.T) {
+	cache := New(OptionExpirationJitter(time.Minute))
+	start := time.Now()
+	cache.Set([]byte("key"), []byte("data"), time.Minute)
+
+	meta, _ := cache.GetMeta([]byte("key"))
+	if meta.Expiration.Before(start.Add(time.Minute)) || meta.Expiration.After(time.Now().Add(2*time.Minute)) {
+		t.Errorf("Expiration %v is out of jitter range", meta.Expiration)
+	}
+}
+
+func TestCacheScan(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("a:1"), []byte("data"), 0)
+	cache.Set([]byte("a:2"), []byte("data"), 0)
+	cache.Set([]byte("b:1"), []byte("data"), 0)
+
+	want := [][]byte{[]byte("a:1"), []byte("a:2")}
+	if keys := cache.Scan("a:"); !reflect.DeepEqual(keys, want) {
+		t.Errorf("%v != %v", keys, want)
+	}
+	if keys := cache.Scan(""); len(keys) != 3 {
+		t.Errorf("%v != %v", len(keys), 3)
+	}
+}
+
+func TestCacheGetKeysExpiring(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("expired"), []byte("data"), time.Millisecond)
+	cache.Set([]byte("soon"), []byte("data"), 10*time.Second)
+	cache.Set([]byte("later"), []byte("data"), time.Hour)
+	time.Sleep(5 * time.Millisecond)
+
+	want := [][]byte{[]byte("soon")}
+	if keys := cache.GetKeysExpiring(30 * time.Second); !reflect.DeepEqual(keys, want) {
+		t.Errorf("%v != %v", keys, want)
+	}
+}
+
+func TestCacheRandomKey(t *testing.T) {
+	cache := New()
+	if _, err := cache.RandomKey(); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+
+	cache.Set([]byte("key"), []byte("data"), 0)
+	if key, err := cache.RandomKey(); err != nil || !reflect.DeepEqual(key, []byte("key")) {
+		t.Errorf("%v != %v", key, []byte("key"))
+	}
+}
+
+func TestCacheTTL(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("data"), time.Minute)
+
+	if ttl, err := cache.TTL([]byte("key")); err != nil || ttl <= 0 || ttl > time.Minute {
+		t.Errorf("Unexpected TTL %v (%v)", ttl, err)
+	}
+	if _, err := cache.TTL([]byte("missing")); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestCacheGetOrSet(t *testing.T) {
+	cache := New()
+	calls := 0
+	fn := func() ([]byte, error) {
+		calls++
+		return []byte("data"), nil
+	}
+
+	for i := 0; i < 2; i++ {
+		value, err := cache.GetOrSet([]byte("key"), fn, 0)
+		if err != nil || !reflect.DeepEqual(value, []byte("data")) {
+			t.Errorf("%v != %v", value, []byte("data"))
+		}
+	}
+	if calls != 1 {
+		t.Errorf("%v != %v", calls, 1)
+	}
+}
+
+func TestCacheGetXFetch(t *testing.T) {
+	cache := New()
+	calls := 0
+	fn := func() ([]byte, error) {
+		calls++
+		return []byte("data"), nil
+	}
+
+	for _, c := range []struct {
+		recompute time.Duration
+		want      int
+	}{
+		{0, 1}, {0, 1}, {1000 * time.Hour, 2},
+	} {
+		recomputeTime := func() time.Duration { return c.recompute }
+		if _, err := cache.GetXFetch([]byte("key"), 1.0, recomputeTime, fn, time.Minute); err != nil {
+			t.Errorf("GetXFetch error: %s", err.Error())
+		}
+		if calls != c.want {
+			t.Errorf("%v != %v", calls, c.want)
+		}
+	}
+}
+
+func TestCacheFlush(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("data"), 0)
+	cache.Flush()
+
+	if cache.Exists([]byte("key")) {
+		t.Errorf("Cache is not empty, but expecting nothing")
+	}
+	if err := cache.Set([]byte("key"), []byte("data"), 0); err != nil {
+		t.Errorf("Set error: %s", err.Error())
+	}
+}
+
+func TestCacheFlushStores(t *testing.T) {
+	cache := New(OptionAtomicCounters(4))
+	cache.SetWithTags([]byte("tagged"), []byte("data"), 0, []string{"tag"})
+	cache.SetNegative([]byte("negative"), 0)
+	cache.Incr([]byte("counter"))
+	cache.Flush()
+
+	if _, err := cache.DeleteByTag("tag"); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+	if len(cache.keyTags) != 0 {
+		t.Errorf("%v != %v", len(cache.keyTags), 0)
+	}
+	if cache.IsNegative([]byte("negative")) {
+		t.Errorf("Negative record is not removed")
+	}
+	if cache.Exists([]byte("counter")) || len(cache.counterIndex) != 0 || len(cache.counterFree) != 4 {
+		t.Errorf("Counter is not removed")
+	}
+}
+
+func TestCacheUpdate(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("data"), 0)
+
+	for _, c := range []struct {
+		size int
+		err  error
+	}{
+		{8, nil}, {1024, nil}, {4096, nil}, {16, nil}, {32, ErrNotFound},
+	} {
+		err := cache.Update([]byte("key"), func(current []byte) ([]byte, error) {
+			if c.err != nil {
+				return nil, c.err
+			}
+			return make([]byte, c.size), nil
+		})
+		if err != c.err {
+			t.Errorf("%v != %v", err, c.err)
+		}
+	}
+
+	if value, err := cache.Get([]byte("key")); err != nil || len(value) != 16 {
+		t.Errorf("%v != %v", len(value), 16)
+	}
+	if err := cache.Update([]byte("missing"), func(current []byte) ([]byte, error) { return current, nil }); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestCacheSwap(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("old"), 0)
+
+	old, err := cache.Swap([]byte("key"), make([]byte, 1024), 0)
+	if err != nil || !reflect.DeepEqual(old, []byte("old")) {
+		t.Errorf("%v != %v", old, []byte("old"))
+	}
+	if value, err := cache.Get([]byte("key")); err != nil || len(value) != 1024 {
+		t.Errorf("%v != %v", len(value), 1024)
+	}
+	if _, err := cache.Swap([]byte("missing"), []byte("new"), 0); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestCacheDeletePrefix(t *testing.T) {
+	for _, backend := range []LookupBackend{NewBTreeLookup(3), NewHashmapLookup()} {
+		clock := NewFakeClock(time.Now())
+		cache := New(OptionLookupBackend(backend), OptionClock(clock))
+		for _, key := range []string{"a", "user:1", "user:2", "users", "z"} {
+			cache.Set([]byte(key), []byte("data"), time.Hour)
+		}
+		cache.Set([]byte("user:expired"), []byte("data"), time.Second)
+		clock.Advance(2 * time.Second)
+		events, stop := cache.Watch([]byte("user:1"))
+
+		if deleted, err := cache.DeletePrefix([]byte("user:")); err != nil || deleted != 2 {
+			t.Errorf("%T: %v != %v", backend, deleted, 2)
+		}
+		if size := backend.Size(); size != 3 {
+			t.Errorf("%T: %v != %v", backend, size, 3)
+		}
+		select {
+		case event := <-events:
+			if event.Type != EventDelete {
+				t.Errorf("%T: %v != %v", backend, event.Type, EventDelete)
+			}
+		default:
+			t.Errorf("%T: Missing delete event", backend)
+		}
+		stop()
+
+		want := [][]byte{[]byte("a"), []byte("users"), []byte("z")}
+		if keys := cache.Scan(""); !reflect.DeepEqual(keys, want) {
+			t.Errorf("%T: %v != %v", backend, keys, want)
+		}
+	}
+}
+
+func TestCacheLoader(t *testing.T) {
+	cache := New(OptionLoader(func(ctx context.Context, key []byte) ([]byte, time.Duration, error) {
+		if string(key) == "missing" {
+			return nil, 0, errors.New("not found in backing store")
+		}
+		return []byte("loaded"), time.Minute, nil
+	}))
+
+	if value, err := cache.Get([]byte("key")); err != nil || !reflect.DeepEqual(value, []byte("loaded")) {
+		t.Errorf("%v != %v", value, []byte("loaded"))
+	}
+	if !cache.Exists([]byte("key")) {
+		t.Errorf("Loaded record was not stored")
+	}
+	if _, err := cache.Get([]byte("missing")); err == nil {
+		t.Errorf("Expecting loader error")
+	}
+	if !cache.Stats().LoaderEnabled {
+		t.Errorf("Expecting enabled loader in stats")
+	}
+}
+
+func TestCacheStore(t *testing.T) {
+	stored := make(chan string, 2)
+	failed := make(chan string, 2)
+	cache := New(
+		OptionStore(func(ctx context.Context, key, value []byte) error {
+			if string(key) == "fail" {
+				return errors.New("backing store error")
+			}
+			stored <- string(key)
+			return nil
+		}),
+		OptionOnStoreError(func(key []byte, err error) {
+			failed <- string(key)
+		}),
+	)
+
+	cache.SetNoStore([]byte("skip"), []byte("data"), 0)
+	cache.Set([]byte("key"), []byte("data"), 0)
+	cache.Set([]byte("fail"), []byte("data"), 0)
+
+	for _, c := range []struct {
+		ch   chan string
+		want string
+	}{
+		{stored, "key"}, {failed, "fail"},
+	} {
+		select {
+		case key := <-c.ch:
+			if key != c.want {
+				t.Errorf("%v != %v", key, c.want)
+			}
+		case <-time.After(time.Second):
+			t.Errorf("Store function was not called for %v", c.want)
+		}
+	}
+}
+
+func TestCacheType(t *testing.T) {
+	cache := New()
+	for _, c := range []struct {
+		size int
+		want string
+	}{
+		{1, "small"}, {512, "small"}, {513, "medium"}, {2048, "medium"}, {2049, "large"},
+	} {
+		if err := cache.Set([]byte("key"), make([]byte, c.size), 0); err != nil {
+			t.Errorf("Set error: %s", err.Error())
+		}
+
+		if section, err := cache.Type([]byte("key")); err != nil {
+			t.Errorf("Type error: %s", err.Error())
+		} else if section != c.want {
+			t.Errorf("%v != %v", section, c.want)
+		}
+	}
+
+	if _, err := cache.Type([]byte("missing")); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestCacheGetMeta(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), make([]byte, 1024), 0)
+	cache.Set([]byte("expired"), []byte("data"), 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+
+	meta, err := cache.GetMeta([]byte("key"))
+	if err != nil {
+		t.Errorf("GetMeta error: %s", err.Error())
+	}
+	if meta.ShardSection != MDSH {
+		t.Errorf("%v != %v", meta.ShardSection, MDSH)
+	}
+
+	if _, err := cache.GetMeta([]byte("expired")); err != ErrExpired {
+		t.Errorf("Expecting error 'ErrExpired'")
+	}
+	if _, err := cache.GetMeta([]byte("missing")); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestCacheLogger(t *testing.T) {
+	var buf bytes.Buffer
+	cache := New(OptionLogger(slog.New(slog.NewTextHandler(&buf, nil))))
+
+	cache.Set([]byte("key"), []byte("data"), 10*time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	cache.collectGarbage()
+
+	if !strings.Contains(buf.String(), "keys_evicted=1") {
+		t.Errorf("Expecting garbage collection log record, got: %s", buf.String())
+	}
+}
+
+func TestCacheSectionMigration(t *testing.T) {
+	cache := New(OptionRecordSizeSmall(8), OptionRecordSizeMedium(16), OptionRecordSizeLarge(32), OptionMaxRecords(1), OptionMaxShardsSmall(1), OptionMaxShardsMedium(1), OptionMaxShardsLarge(1))
+
+	medium := []byte("medium-size-data")
+	cache.Set([]byte("key"), []byte("small"), 0)
+	cache.Set([]byte("key"), medium, 0)
+
+	// Small section slot has to be released by migration
+	cache.Set([]byte("other"), []byte("small"), 0)
+
+	if data, err := cache.Get([]byte("key")); err != nil || !reflect.DeepEqual(data, medium) {
+		t.Errorf("%q != %q", data, medium)
+	}
+	if data, err := cache.Get([]byte("other")); err != nil || !reflect.DeepEqual(data, []byte("small")) {
+		t.Errorf("%q != %q", data, []byte("small"))
+	}
+	if stats := cache.Stats(); stats.BufferLen != 0 {
+		t.Errorf("%v != %v", stats.BufferLen, 0)
+	}
+}
+
+func TestCacheSectionMigrationLarge(t *testing.T) {
+	cache := New(OptionRecordSizeSmall(8), OptionRecordSizeMedium(16), OptionRecordSizeLarge(32), OptionMaxRecords(1), OptionMaxShardsSmall(2), OptionMaxShardsMedium(1), OptionMaxShardsLarge(1))
+
+	large := []byte("large-size-data-over-sixteen")
+	cache.Set([]byte("key1"), []byte("small"), 0)
+	cache.Set([]byte("key2"), []byte("small"), 0)
+	cache.Set([]byte("key2"), large, 0)
+
+	if data, err := cache.Get([]byte("key2")); err != nil || !reflect.DeepEqual(data, large) {
+		t.Errorf("%q != %q", data, large)
+	}
+	if active := len(cache.sections[0].shardsActive); active != 1 {
+		t.Errorf("%v != %v", active, 1)
+	}
+	if active := len(cache.sections[2].shardsActive); active != 1 {
+		t.Errorf("%v != %v", active, 1)
+	}
+}
+
+func TestCacheBufferReplay(t *testing.T) {
+	cache := New(OptionMaxRecords(1), OptionMaxShardsSmall(1))
+	cache.Set([]byte("expired"), []byte("data"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	// Memory is full, so the record is buffered and garbage collection starts
+	if err := cache.Set([]byte("key"), []byte("data"), 0); err != nil {
+		t.Errorf("Set error: %s", err.Error())
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !cache.Exists([]byte("key")) {
+		if time.Now().After(deadline) {
+			t.Fatalf("Buffered record was not stored")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestCacheNoExpiry(t *testing.T) {
+	cache := New(OptionDefaultTTL(time.Millisecond))
+	cache.Set([]byte("permanent"), []byte("data"), NoExpiry)
+	cache.Set([]byte("default"), []byte("data"), 0)
+	time.Sleep(5 * time.Millisecond)
+	cache.TrimExpired()
+
+	if _, err := cache.Get([]byte("permanent")); err != nil {
+		t.Errorf("Get error: %s", err.Error())
+	}
+	if ttl, err := cache.TTL([]byte("permanent")); err != nil || ttl != NoExpiry {
+		t.Errorf("%v != %v", ttl, NoExpiry)
+	}
+	if _, err := cache.Get([]byte("default")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestCacheChecksums(t *testing.T) {
+	cache := New(OptionRecordSizeSmall(8), OptionRecordSizeMedium(16), OptionRecordSizeLarge(32), OptionChecksums(true))
+	data := []byte("12345678")
+	cache.Set([]byte("key"), data, 0)
+
+	if result, err := cache.Get([]byte("key")); err != nil || !reflect.DeepEqual(result, data) {
+		t.Errorf("%q != %q", result, data)
+	}
+
+	val, _ := cache.GetMeta([]byte("key"))
+	cache.sections[0].shards[val.ShardIndex].slots[val.RecordIndex].data[0] ^= 0xFF
+
+	if _, err := cache.Get([]byte("key")); err != ErrChecksumMismatch {
+		t.Errorf("Expecting error 'ErrChecksumMismatch'")
+	}
+}
+
+func TestCacheEagerAllocation(t *testing.T) {
+	cache := New(OptionRecordSizeSmall(8), OptionRecordSizeMedium(16), OptionRecordSizeLarge(32), OptionMaxRecords(1), OptionMaxShardsSmall(3), OptionMaxShardsMedium(1), OptionMaxShardsLarge(1), OptionEagerAllocation(true))
+
+	cache.Set([]byte("key1"), []byte("data"), 0)
+	cache.Set([]byte("key2"), []byte("data"), 0)
+	cache.Delete([]byte("key2"))
+
+	for i, shard := range cache.sections[0].shards {
+		if shard == nil {
+			t.Errorf("Shard %v is not allocated", i)
+		}
+	}
+	if stats := cache.Stats(); stats.MemoryBytesUsed != 3*8+16+32 {
+		t.Errorf("%v != %v", stats.MemoryBytesUsed, 3*8+16+32)
+	}
+	if data, err := cache.Get([]byte("key1")); err != nil || string(data) != "data" {
+		t.Errorf("%q != %q", data, "data")
+	}
+}
+
+func TestCacheGetInto(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("data"), 0)
+
+	buf := make([]byte, 2)
+	if n, err := cache.GetInto([]byte("key"), buf); err != ErrBufferTooSmall || n != 4 {
+		t.Errorf("%v != %v", n, 4)
+	}
+
+	buf = make([]byte, 8)
+	if n, err := cache.GetInto([]byte("key"), buf); err != nil || !reflect.DeepEqual(buf[:n], []byte("data")) {
+		t.Errorf("%q != %q", buf[:n], []byte("data"))
+	}
+
+	if _, err := cache.GetInto([]byte("missing"), buf); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestCacheTrimExpired(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("data"), 0)
+	cache.Set([]byte("expired1"), []byte("data"), time.Millisecond)
+	cache.Set([]byte("expired2"), []byte("data"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	if trimmed := cache.TrimExpired(); trimmed != 2 {
+		t.Errorf("%v != %v", trimmed, 2)
+	}
+	if items := cache.Stats().Items; items != 1 {
+		t.Errorf("%v != %v", items, 1)
+	}
+}
+
 func benchmarkCacheNew(recordCount uint32, b *testing.B) {
 	b.ReportAllocs()
 
@@ -385,3 +1026,537 @@ func newHasCache() *has.Cache {
 	cache, _ := has.New(262144)
 	return cache
 }
+
+func TestCacheVersionBump(t *testing.T) {
+	cache := New(OptionVersion(7), OptionAtomicCounters(1))
+
+	cache.Set([]byte("key"), []byte("data"), 0)
+	cache.Set([]byte("counter"), []byte("1"), 0)
+
+	if version := cache.Bump(); version != 8 {
+		t.Errorf("%v != %v", version, 8)
+	}
+
+	if _, err := cache.Get([]byte("key")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+	if _, err := cache.Get([]byte("counter")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+	if cache.Exists([]byte("key")) {
+		t.Errorf("Record of previous version exists")
+	}
+
+	cache.Set([]byte("key"), []byte("new"), 0)
+	if value, err := cache.Get([]byte("key")); err != nil || !reflect.DeepEqual(value, []byte("new")) {
+		t.Errorf("%v != %v", value, []byte("new"))
+	}
+
+	cache.CollectGarbage()
+	if items := cache.Stats().Items; items != 1 {
+		t.Errorf("%v != %v", items, 1)
+	}
+}
+
+func TestCacheGetAndDelete(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("data"), 0)
+
+	if data, err := cache.GetAndDelete([]byte("key")); err != nil || !reflect.DeepEqual(data, []byte("data")) {
+		t.Errorf("%v != %v", data, []byte("data"))
+	}
+	if _, err := cache.GetAndDelete([]byte("key")); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestCacheShardReuse(t *testing.T) {
+	cache := New(OptionMaxRecords(2), OptionMaxShardsSmall(3))
+
+	for i := 0; i < 4; i++ {
+		if err := cache.Set([]byte{'k', byte(i)}, []byte("data"), 0); err != nil {
+			t.Errorf("Set error: %s", err.Error())
+		}
+	}
+
+	section := &cache.sections[SMSH-1]
+	if active := len(section.shardsActive); active != 2 {
+		t.Errorf("%v != %v", active, 2)
+	}
+	if avail := len(section.shardsAvail); avail != 1 {
+		t.Errorf("%v != %v", avail, 1)
+	}
+}
+
+func TestCacheShardRelease(t *testing.T) {
+	cache := New(OptionMaxRecords(2), OptionMaxShardsSmall(3))
+
+	for round := 0; round < 2; round++ {
+		for i := 0; i < 6; i++ {
+			if err := cache.Set([]byte{'k', byte(i)}, []byte("data"), 0); err != nil {
+				t.Errorf("[%d] Set error: %s", round, err.Error())
+			}
+		}
+		for i := 0; i < 6; i++ {
+			if err := cache.Delete([]byte{'k', byte(i)}); err != nil {
+				t.Errorf("[%d] Delete error: %s", round, err.Error())
+			}
+		}
+
+		section := &cache.sections[SMSH-1]
+		if active := len(section.shardsActive); active != 1 {
+			t.Errorf("[%d] %v != %v", round, active, 1)
+		}
+		if avail := len(section.shardsAvail); avail != 2 {
+			t.Errorf("[%d] %v != %v", round, avail, 2)
+		}
+		for _, shardIndex := range section.shardsActive {
+			if section.shards[shardIndex] == nil {
+				t.Errorf("[%d] Active shard %v is released", round, shardIndex)
+			}
+		}
+	}
+}
+
+func TestCacheGetOrSetCtx(t *testing.T) {
+	cache := New()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+
+	_, err := cache.GetOrSetCtx(ctx, []byte("key"), func(ctx context.Context) ([]byte, error) {
+		<-ctx.Done()
+		return []byte("late"), nil
+	}, 0)
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expecting error 'context.DeadlineExceeded'")
+	}
+	if cache.Exists([]byte("key")) {
+		t.Errorf("Data of cancelled call are stored")
+	}
+
+	value, err := cache.GetOrSetCtx(context.Background(), []byte("key"), func(ctx context.Context) ([]byte, error) {
+		return []byte("data"), nil
+	}, 0)
+	if err != nil || !reflect.DeepEqual(value, []byte("data")) {
+		t.Errorf("%v != %v", value, []byte("data"))
+	}
+	if len(cache.getOrSetGroup.calls) != 0 {
+		t.Errorf("%v != %v", len(cache.getOrSetGroup.calls), 0)
+	}
+}
+
+func TestCacheL2(t *testing.T) {
+	l2 := New()
+	cache := New(OptionL2Cache(l2))
+
+	l2.Set([]byte("remote"), []byte("data"), time.Minute)
+	if value, err := cache.Get([]byte("remote")); err != nil || !reflect.DeepEqual(value, []byte("data")) {
+		t.Errorf("%v != %v", value, []byte("data"))
+	}
+	if ttl, err := cache.TTL([]byte("remote")); err != nil || ttl > time.Minute {
+		t.Errorf("TTL %v is not taken from L2 cache", ttl)
+	}
+
+	cache.Set([]byte("local"), []byte("data"), 0)
+	for i := 0; i < 100 && !l2.Exists([]byte("local")); i++ {
+		time.Sleep(time.Millisecond)
+	}
+	if !l2.Exists([]byte("local")) {
+		t.Errorf("Record is not written to L2 cache")
+	}
+
+	if _, err := cache.Get([]byte("missing")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestCacheExpireIf(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("session:1"), []byte("data"), time.Minute)
+	cache.Set([]byte("session:2"), []byte("data"), time.Hour)
+	cache.Set([]byte("user:1"), []byte("data"), time.Minute)
+
+	updated := cache.ExpireIf(func(key []byte, meta LookupRecord) bool {
+		return bytes.HasPrefix(key, []byte("session:")) && time.Until(meta.Expiration) < 5*time.Minute
+	}, 2*time.Hour)
+	if updated != 1 {
+		t.Errorf("%v != %v", updated, 1)
+	}
+
+	if ttl, _ := cache.TTL([]byte("session:1")); ttl <= time.Hour {
+		t.Errorf("TTL %v is not updated", ttl)
+	}
+	if ttl, _ := cache.TTL([]byte("user:1")); ttl > time.Minute {
+		t.Errorf("TTL %v of different key is updated", ttl)
+	}
+}
+
+func TestCacheMaxItemSize(t *testing.T) {
+	cache := New(OptionMaxItemSize(100))
+	if err := cache.Set([]byte("key"), make([]byte, 101), 0); !errors.Is(err, ErrDataLimit) {
+		t.Errorf("Expecting error 'ErrDataLimit'")
+	}
+
+	cache = New(OptionMaxItemSize(1<<20), OptionMaxShardsLarge(2))
+	data := make([]byte, 1<<20)
+	data[0] = 1
+	if err := cache.Set([]byte("key1"), data, 0); err != nil {
+		t.Errorf("Set error: %s", err.Error())
+	}
+	if value, err := cache.Get([]byte("key1")); err != nil || !reflect.DeepEqual(value, data) {
+		t.Errorf("Unexpected overflow record")
+	}
+	if section, _ := cache.Type([]byte("key1")); section != "overflow" {
+		t.Errorf("%v != %v", section, "overflow")
+	}
+
+	cache.Set([]byte("key2"), data, 0)
+	if err := cache.Set([]byte("key3"), data, 0); err != nil {
+		t.Errorf("Set error: %s", err.Error())
+	}
+	if cache.Exists([]byte("key3")) || cache.BufferLen() != 1 {
+		t.Errorf("Overflow section is not full after %v records", 2)
+	}
+
+	if err := cache.Set([]byte("key4"), make([]byte, 1<<20+1), 0); !errors.Is(err, ErrDataLimit) {
+		t.Errorf("Expecting error 'ErrDataLimit'")
+	}
+}
+
+func TestCacheSetAbsolute(t *testing.T) {
+	cache := New()
+
+	expiresAt := time.Now().Add(time.Hour).Round(0)
+	if err := cache.SetAbsolute([]byte("key"), []byte("data"), expiresAt); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if data, err := cache.Get([]byte("key")); err != nil || string(data) != "data" {
+		t.Errorf("%s, %v != data, <nil>", data, err)
+	}
+	if val, _ := cache.lookup.Get("key"); !val.Expiration.Equal(expiresAt) {
+		t.Errorf("%v != %v", val.Expiration, expiresAt)
+	}
+
+	if err := cache.SetAbsolute([]byte("past"), []byte("data"), time.Now().Add(-time.Second)); err != ErrExpiredInput {
+		t.Errorf("Expecting error 'ErrExpiredInput'")
+	}
+	if cache.Exists([]byte("past")) {
+		t.Errorf("Record stored with past expiration")
+	}
+}
+
+func TestCacheGetRange(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("0123456789"), 0)
+	cache.Set([]byte("expired"), []byte("0123456789"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+
+	if data, err := cache.GetRange([]byte("key"), 2, 5); err != nil || string(data) != "234" {
+		t.Errorf("%s, %v != 234, <nil>", data, err)
+	}
+	if data, err := cache.GetRange([]byte("key"), 0, 10); err != nil || string(data) != "0123456789" {
+		t.Errorf("%s, %v != 0123456789, <nil>", data, err)
+	}
+
+	for _, r := range [][2]int{{-1, 2}, {0, 11}, {5, 4}} {
+		if _, err := cache.GetRange([]byte("key"), r[0], r[1]); err != ErrOutOfRange {
+			t.Errorf("Expecting error 'ErrOutOfRange'")
+		}
+	}
+
+	if _, err := cache.GetRange([]byte("missing"), 0, 1); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+	if _, err := cache.GetRange([]byte("expired"), 0, 1); err != ErrExpired {
+		t.Errorf("Expecting error 'ErrExpired'")
+	}
+}
+
+func TestCacheZeroLengthValue(t *testing.T) {
+	for _, checksums := range []bool{false, true} {
+		cache := New(OptionChecksums(checksums))
+
+		for _, data := range [][]byte{{}, nil} {
+			if err := cache.Set([]byte("key"), data, 0); err != nil {
+				t.Fatalf("[%v] Set error: %s", checksums, err.Error())
+			}
+
+			if data, err := cache.Get([]byte("key")); err != nil || data == nil || len(data) != 0 {
+				t.Errorf("[%v] %#v, %v != []byte{}, <nil>", checksums, data, err)
+			}
+			if !cache.Exists([]byte("key")) {
+				t.Errorf("[%v] Zero-length record does not exist", checksums)
+			}
+			if data, err := cache.GetAndDelete([]byte("key")); err != nil || data == nil || len(data) != 0 {
+				t.Errorf("[%v] %#v, %v != []byte{}, <nil>", checksums, data, err)
+			}
+			if cache.Exists([]byte("key")) {
+				t.Errorf("[%v] Deleted record exists", checksums)
+			}
+		}
+	}
+}
+
+func TestCacheReadOnly(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("data"), 0)
+	cache.SetReadOnly(true)
+
+	if err := cache.Set([]byte("key"), []byte("new"), 0); err != ErrReadOnly {
+		t.Errorf("Expecting error 'ErrReadOnly'")
+	}
+	if err := cache.Delete([]byte("key")); err != ErrReadOnly {
+		t.Errorf("Expecting error 'ErrReadOnly'")
+	}
+	if err := cache.Update([]byte("key"), func(current []byte) ([]byte, error) { return current, nil }); err != ErrReadOnly {
+		t.Errorf("Expecting error 'ErrReadOnly'")
+	}
+	if _, err := cache.MDelete([][]byte{[]byte("key")}); err != ErrReadOnly {
+		t.Errorf("Expecting error 'ErrReadOnly'")
+	}
+	if errs := cache.SetEach([]CacheItem{{Key: []byte("key"), Data: []byte("new")}}, 0); errs[0] != ErrReadOnly {
+		t.Errorf("Expecting error 'ErrReadOnly'")
+	}
+	if err := cache.Flush(); err != ErrReadOnly {
+		t.Errorf("Expecting error 'ErrReadOnly'")
+	}
+
+	if data, err := cache.Get([]byte("key")); err != nil || string(data) != "data" {
+		t.Errorf("%s, %v != data, <nil>", data, err)
+	}
+
+	cache.SetReadOnly(false)
+	if err := cache.Set([]byte("key"), []byte("new"), 0); err != nil {
+		t.Errorf("Set error: %s", err.Error())
+	}
+
+	if cache := New(OptionReadOnly(true)); cache.Set([]byte("key"), []byte("data"), 0) != ErrReadOnly {
+		t.Errorf("Expecting error 'ErrReadOnly'")
+	}
+}
+
+func TestCacheReadOnlyConcurrent(t *testing.T) {
+	for _, cache := range []*AtomicCache{New(), New(OptionAtomicCounters(4))} {
+		started := make(chan struct{})
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; ; i++ {
+				if i == 10 {
+					close(started)
+				}
+				if _, err := cache.Incr([]byte("counter")); err == ErrReadOnly {
+					return
+				}
+			}
+		}()
+
+		<-started
+		cache.SetReadOnly(true)
+		value, _ := cache.Get([]byte("counter"))
+		<-done
+
+		if data, _ := cache.Get([]byte("counter")); string(data) != string(value) {
+			t.Errorf("Counter was incremented in read-only mode: %s != %s", data, value)
+		}
+	}
+}
+
+func TestCacheReadOnlyMethods(t *testing.T) {
+	cache := New(OptionAtomicCounters(4))
+	cache.Set([]byte("key"), []byte("data"), time.Minute)
+	cache.Set([]byte("counter"), []byte("1"), time.Minute)
+	cache.SetWithTags([]byte("tagged"), []byte("data"), time.Minute, []string{"tag"})
+	token, _, _ := cache.LockKey([]byte("lock"), time.Minute)
+	reservation, _ := cache.Reserve(SMSH, 1)
+	version := cache.version.Load()
+
+	var dump bytes.Buffer
+	cache.Dump(&dump)
+
+	cache.SetReadOnly(true)
+
+	for name, fn := range map[string]func() error{
+		"Set":        func() error { return cache.Set([]byte("key"), []byte("new"), 0) },
+		"SetCtx":     func() error { return cache.SetCtx(context.Background(), []byte("key"), []byte("new"), 0) },
+		"SetNoStore": func() error { return cache.SetNoStore([]byte("key"), []byte("new"), 0) },
+		"SetAbsolute": func() error {
+			return cache.SetAbsolute([]byte("key"), []byte("new"), time.Now().Add(time.Hour))
+		},
+		"SetWithTags": func() error { return cache.SetWithTags([]byte("key"), []byte("new"), 0, []string{"tag"}) },
+		"SetEach": func() error {
+			return cache.SetEach([]CacheItem{{Key: []byte("key"), Data: []byte("new")}}, 0)[0]
+		},
+		"SetFromReader": func() error { return cache.SetFromReader([]byte("key"), strings.NewReader("new"), 3, 0) },
+		"SetReserved":   func() error { return cache.SetReserved(reservation, []byte("key"), []byte("new"), 0) },
+		"SetNegative":   func() error { return cache.SetNegative([]byte("missing"), 0) },
+		"Update": func() error {
+			return cache.Update([]byte("key"), func(current []byte) ([]byte, error) { return []byte("new"), nil })
+		},
+		"Swap": func() error {
+			_, err := cache.Swap([]byte("key"), []byte("new"), 0)
+			return err
+		},
+		"Delete": func() error { return cache.Delete([]byte("key")) },
+		"DeleteIf": func() error {
+			_, err := cache.DeleteIf([]byte("key"), func([]byte) bool { return true })
+			return err
+		},
+		"GetAndDelete": func() error {
+			_, err := cache.GetAndDelete([]byte("key"))
+			return err
+		},
+		"DeletePrefix": func() error {
+			_, err := cache.DeletePrefix([]byte("k"))
+			return err
+		},
+		"DeleteByTag": func() error {
+			_, err := cache.DeleteByTag("tag")
+			return err
+		},
+		"MDelete": func() error {
+			_, err := cache.MDelete([][]byte{[]byte("key")})
+			return err
+		},
+		"Expire": func() error { return cache.Expire([]byte("key"), time.Hour) },
+		"IncrExpire": func() error {
+			_, err := cache.IncrExpire([]byte("key"), time.Hour)
+			return err
+		},
+		"ExpireIf": func() error {
+			if cache.ExpireIf(func([]byte, LookupRecord) bool { return true }, time.Hour) != 0 {
+				return nil
+			}
+			return ErrReadOnly
+		},
+		"Touch": func() error { return cache.Touch([]byte("key"), time.Hour) },
+		"Incr": func() error {
+			_, err := cache.Incr([]byte("counter"))
+			return err
+		},
+		"IncrBy": func() error {
+			_, err := cache.IncrBy([]byte("counter"), 2)
+			return err
+		},
+		"Decr": func() error {
+			_, err := cache.Decr([]byte("counter"))
+			return err
+		},
+		"DecrBy": func() error {
+			_, err := cache.DecrBy([]byte("counter"), 2)
+			return err
+		},
+		"GetAndReset": func() error {
+			_, err := cache.GetAndReset([]byte("counter"))
+			return err
+		},
+		"LockKey": func() error {
+			_, _, err := cache.LockKey([]byte("other-lock"), time.Minute)
+			return err
+		},
+		"UnlockKey": func() error {
+			_, err := cache.UnlockKey([]byte("lock"), token)
+			return err
+		},
+		"ExtendLock": func() error {
+			_, err := cache.ExtendLock([]byte("lock"), token, time.Hour)
+			return err
+		},
+		"Reserve": func() error {
+			_, err := cache.Reserve(SMSH, 1)
+			return err
+		},
+		"Pipeline.Set":    func() error { return cache.Pipeline().Set([]byte("key"), []byte("new"), 0).Exec()[0].Err },
+		"Pipeline.Delete": func() error { return cache.Pipeline().Delete([]byte("key")).Exec()[0].Err },
+		"Pipeline.Expire": func() error {
+			return cache.Pipeline().Expire([]byte("key"), time.Hour).Exec()[0].Err
+		},
+		"MergeFrom": func() error {
+			src := New()
+			src.Set([]byte("key"), []byte("new"), 0)
+			_, _, err := cache.MergeFrom(src, OverwriteExisting)
+			return err
+		},
+		"WarmUp": func() error {
+			_, err := cache.WarmUp(bytes.NewReader(dump.Bytes()))
+			return err
+		},
+		"Bump": func() error {
+			if cache.Bump() != version {
+				return nil
+			}
+			return ErrReadOnly
+		},
+		"Flush": func() error { return cache.Flush() },
+	} {
+		if err := fn(); err != ErrReadOnly {
+			t.Errorf("[%s] Expecting error 'ErrReadOnly', got %v", name, err)
+		}
+	}
+
+	for key, want := range map[string]string{"key": "data", "counter": "1", "tagged": "data", "lock": string(token)} {
+		if data, err := cache.Get([]byte(key)); err != nil || string(data) != want {
+			t.Errorf("[%s] %q != %q", key, data, want)
+		}
+	}
+	if ttl, _ := cache.TTL([]byte("key")); ttl > time.Minute {
+		t.Errorf("Expiration is modified in read-only mode")
+	}
+	if cache.IsNegative([]byte("missing")) || cache.Exists([]byte("other-lock")) {
+		t.Errorf("Record is stored in read-only mode")
+	}
+}
+
+func TestCacheIncrExpire(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	cache := New(OptionClock(clock))
+	cache.Set([]byte("key"), []byte("data"), 10*time.Second)
+	cache.Set([]byte("forever"), []byte("data"), NoExpiry)
+
+	clock.Advance(4 * time.Second)
+	if ttl, err := cache.IncrExpire([]byte("key"), 10*time.Second); err != nil || ttl != 16*time.Second {
+		t.Errorf("%v, %v != %v, <nil>", ttl, err, 16*time.Second)
+	}
+	if ttl, _ := cache.TTL([]byte("key")); ttl != 16*time.Second {
+		t.Errorf("%v != %v", ttl, 16*time.Second)
+	}
+
+	if ttl, err := cache.IncrExpire([]byte("forever"), time.Second); err != nil || ttl != NoExpiry {
+		t.Errorf("%v, %v != %v, <nil>", ttl, err, NoExpiry)
+	}
+
+	if ttl, err := cache.IncrExpire([]byte("key"), -20*time.Second); err != nil || ttl != 0 {
+		t.Errorf("%v, %v != 0, <nil>", ttl, err)
+	}
+	if _, err := cache.IncrExpire([]byte("key"), time.Second); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+	if _, err := cache.IncrExpire([]byte("missing"), time.Second); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestCacheDeleteIf(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("v1"), 0)
+
+	if deleted, err := cache.DeleteIf([]byte("key"), func(data []byte) bool { return string(data) == "v2" }); err != nil || deleted {
+		t.Errorf("%v, %v != false, <nil>", deleted, err)
+	}
+	if !cache.Exists([]byte("key")) {
+		t.Errorf("Record deleted although predicate returned false")
+	}
+
+	if deleted, err := cache.DeleteIf([]byte("key"), func(data []byte) bool { return string(data) == "v1" }); err != nil || !deleted {
+		t.Errorf("%v, %v != true, <nil>", deleted, err)
+	}
+	if cache.Exists([]byte("key")) {
+		t.Errorf("Record was not deleted")
+	}
+
+	if _, err := cache.DeleteIf([]byte("key"), func([]byte) bool { return true }); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
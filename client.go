@@ -0,0 +1,94 @@
+package atomiccache
+
+import (
+	"time"
+)
+
+// DefaultClientFlushThreshold is number of queued operations after which
+// client with enabled auto flush flushes them.
+const DefaultClientFlushThreshold = 64
+
+// clientOperation is operation queued by client with its result.
+type clientOperation struct {
+	set    bool
+	result *PipelineResult
+}
+
+// CacheClient queues Set and Get calls and executes them in bulk by pipeline
+// (see Pipeline), so the cache lock is acquired once per flush instead of once
+// per operation. Results are available in returned PipelineResult after
+// flush. Keys and data are not copied, so they must not be modified until the
+// flush. Client is not thread safe, it is intended to be used by one goroutine
+// (e.g. one request handler).
+type CacheClient struct {
+	pipeline   *Pipeline
+	operations []clientOperation
+	autoFlush  bool
+	threshold  int
+}
+
+// NewClient returns new client of cache memory. Auto flush is disabled.
+func NewClient(c *AtomicCache) *CacheClient {
+	return &CacheClient{pipeline: c.Pipeline(), threshold: DefaultClientFlushThreshold}
+}
+
+// AutoFlush enables or disables flush after number of queued operations
+// reaches flush threshold.
+func (c *CacheClient) AutoFlush(enabled bool) {
+	c.autoFlush = enabled
+}
+
+// SetFlushThreshold sets number of queued operations after which operations
+// are flushed if auto flush is enabled.
+func (c *CacheClient) SetFlushThreshold(n int) {
+	c.threshold = n
+}
+
+// Set queues Set operation. Returned result contains error of the operation
+// after flush. Write-through store is not used.
+func (c *CacheClient) Set(key []byte, data []byte, expire time.Duration) *PipelineResult {
+	c.pipeline.Set(key, data, expire)
+	return c.queue(true)
+}
+
+// Get queues Get operation. Returned result contains data or error of the
+// operation after flush. Loader is not used.
+func (c *CacheClient) Get(key []byte) *PipelineResult {
+	c.pipeline.Get(key)
+	return c.queue(false)
+}
+
+// Pending returns number of queued operations.
+func (c *CacheClient) Pending() int {
+	return len(c.operations)
+}
+
+// Flush executes all queued operations under one lock and fills their
+// results. It returns the first error of Set operations (errors of Get
+// operations, e.g. ErrNotFound, are available only in results).
+func (c *CacheClient) Flush() error {
+	var err error
+
+	for i, result := range c.pipeline.Exec() {
+		*c.operations[i].result = result
+		if err == nil && c.operations[i].set {
+			err = result.Err
+		}
+	}
+	c.operations = c.operations[:0]
+
+	return err
+}
+
+// queue registers result of operation queued to pipeline and flushes queued
+// operations if auto flush threshold is reached.
+func (c *CacheClient) queue(set bool) *PipelineResult {
+	result := &PipelineResult{}
+	c.operations = append(c.operations, clientOperation{set: set, result: result})
+
+	if c.autoFlush && len(c.operations) >= c.threshold {
+		c.Flush()
+	}
+
+	return result
+}
@@ -0,0 +1,57 @@
+package atomiccache
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCacheClient(t *testing.T) {
+	cache := New()
+	client := NewClient(cache)
+
+	set := client.Set([]byte("key"), []byte("data"), 0)
+	get := client.Get([]byte("key"))
+	missing := client.Get([]byte("missing"))
+
+	if client.Pending() != 3 || cache.Exists([]byte("key")) {
+		t.Errorf("Operations are not queued")
+	}
+
+	if err := client.Flush(); err != nil {
+		t.Errorf("Flush error: %s", err.Error())
+	}
+	if set.Err != nil {
+		t.Errorf("Set error: %s", set.Err.Error())
+	}
+	if get.Err != nil || string(get.Data) != "data" {
+		t.Errorf("%s, %v != data, <nil>", get.Data, get.Err)
+	}
+	if !errors.Is(missing.Err, ErrNotFound) {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+	if client.Pending() != 0 {
+		t.Errorf("%v != %v", client.Pending(), 0)
+	}
+
+	client.Set([]byte("key"), make([]byte, 9000), 0)
+	if err := client.Flush(); !errors.Is(err, ErrDataLimit) {
+		t.Errorf("Expecting error 'ErrDataLimit'")
+	}
+}
+
+func TestCacheClientAutoFlush(t *testing.T) {
+	cache := New()
+	client := NewClient(cache)
+	client.AutoFlush(true)
+	client.SetFlushThreshold(2)
+
+	client.Set([]byte("key1"), []byte("data"), 0)
+	if cache.Exists([]byte("key1")) {
+		t.Errorf("Operation flushed before threshold")
+	}
+
+	result := client.Get([]byte("key1"))
+	if client.Pending() != 0 || string(result.Data) != "data" {
+		t.Errorf("Operations are not flushed")
+	}
+}
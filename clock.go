@@ -0,0 +1,90 @@
+package atomiccache
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock is source of time used for expiration of records. Custom clock (e.g.
+// FakeClock) allows to test time dependent behavior without sleeping.
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+// RealClock is clock which uses time package. It is the default clock.
+type RealClock struct{}
+
+// Now returns current local time.
+func (RealClock) Now() time.Time {
+	return time.Now()
+}
+
+// After waits for the duration to elapse and then sends current time on the
+// returned channel.
+func (RealClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+// fakeTimer is channel returned by FakeClock.After with its deadline.
+type fakeTimer struct {
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// FakeClock is clock which is moved forward only by Advance. It is intended
+// for tests. It is thread safe.
+type FakeClock struct {
+	sync.Mutex
+	now    time.Time
+	timers []fakeTimer
+}
+
+// NewFakeClock returns fake clock set to specified time.
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now returns current time of the clock.
+func (f *FakeClock) Now() time.Time {
+	f.Lock()
+	defer f.Unlock()
+
+	return f.now
+}
+
+// After returns channel which receives time of the clock once it is advanced
+// by the duration. If duration is not positive, the channel receives current
+// time immediately.
+func (f *FakeClock) After(d time.Duration) <-chan time.Time {
+	f.Lock()
+	defer f.Unlock()
+
+	ch := make(chan time.Time, 1)
+	if d <= 0 {
+		ch <- f.now
+		return ch
+	}
+
+	f.timers = append(f.timers, fakeTimer{deadline: f.now.Add(d), ch: ch})
+	return ch
+}
+
+// Advance moves the clock forward by the duration and fires all channels
+// returned by After whose deadline has passed.
+func (f *FakeClock) Advance(d time.Duration) {
+	f.Lock()
+	defer f.Unlock()
+
+	f.now = f.now.Add(d)
+
+	timers := f.timers[:0]
+	for _, timer := range f.timers {
+		if timer.deadline.After(f.now) {
+			timers = append(timers, timer)
+			continue
+		}
+		timer.ch <- f.now
+	}
+	f.timers = timers
+}
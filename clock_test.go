@@ -0,0 +1,54 @@
+package atomiccache
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFakeClock(t *testing.T) {
+	start := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+	clock := NewFakeClock(start)
+
+	ch := clock.After(time.Minute)
+	clock.Advance(30 * time.Second)
+	select {
+	case <-ch:
+		t.Errorf("Channel fired before deadline")
+	default:
+	}
+
+	clock.Advance(30 * time.Second)
+	select {
+	case now := <-ch:
+		if !now.Equal(start.Add(time.Minute)) {
+			t.Errorf("%v != %v", now, start.Add(time.Minute))
+		}
+	default:
+		t.Errorf("Channel did not fire after deadline")
+	}
+
+	if now := clock.Now(); !now.Equal(start.Add(time.Minute)) {
+		t.Errorf("%v != %v", now, start.Add(time.Minute))
+	}
+}
+
+func TestCacheClock(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	cache := New(OptionClock(clock))
+	cache.Set([]byte("key"), []byte("data"), time.Hour)
+
+	if ttl, err := cache.TTL([]byte("key")); err != nil || ttl != time.Hour {
+		t.Errorf("%v, %v != %v, <nil>", ttl, err, time.Hour)
+	}
+
+	clock.Advance(time.Hour)
+	if _, err := cache.Get([]byte("key")); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+
+	cache.CollectGarbage()
+	if items := cache.Stats().Items; items != 0 {
+		t.Errorf("%v != %v", items, 0)
+	}
+}
@@ -0,0 +1,57 @@
+package atomiccache
+
+import (
+	"fmt"
+)
+
+// NotFoundError is returned if record of key is not found. It wraps
+// ErrNotFound, so it can be checked by errors.Is.
+type NotFoundError struct {
+	Key []byte
+}
+
+// Error returns error message with the key.
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("%v: %q", ErrNotFound, e.Key)
+}
+
+// Unwrap returns ErrNotFound.
+func (e *NotFoundError) Unwrap() error {
+	return ErrNotFound
+}
+
+// DataLimitError is returned if data are larger than maximum item size. It
+// wraps ErrDataLimit, so it can be checked by errors.Is.
+type DataLimitError struct {
+	Key   []byte
+	Size  int
+	Limit int
+}
+
+// Error returns error message with the key, data size and limit.
+func (e *DataLimitError) Error() string {
+	return fmt.Sprintf("%v: %q (size %d, limit %d)", ErrDataLimit, e.Key, e.Size, e.Limit)
+}
+
+// Unwrap returns ErrDataLimit.
+func (e *DataLimitError) Unwrap() error {
+	return ErrDataLimit
+}
+
+// FullMemoryError is returned if record cannot be stored because memory and
+// buffer of unattended set requests are full. It wraps ErrFullMemory, so it
+// can be checked by errors.Is.
+type FullMemoryError struct {
+	BufferLen int
+	BufferCap int
+}
+
+// Error returns error message with buffer length and capacity.
+func (e *FullMemoryError) Error() string {
+	return fmt.Sprintf("%v (buffer %d/%d)", ErrFullMemory, e.BufferLen, e.BufferCap)
+}
+
+// Unwrap returns ErrFullMemory.
+func (e *FullMemoryError) Unwrap() error {
+	return ErrFullMemory
+}
@@ -0,0 +1,29 @@
+package atomiccache
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestStructuredErrors(t *testing.T) {
+	cache := New(OptionMaxRecords(1), OptionMaxShardsSmall(1), OptionMaxBufferSize(0))
+
+	var notFound *NotFoundError
+	if _, err := cache.Get([]byte("missing")); !errors.As(err, &notFound) || string(notFound.Key) != "missing" || !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expecting error 'NotFoundError'")
+	}
+	if err := cache.Delete([]byte("missing")); !errors.As(err, &notFound) || string(notFound.Key) != "missing" {
+		t.Errorf("Expecting error 'NotFoundError'")
+	}
+
+	var dataLimit *DataLimitError
+	if err := cache.Set([]byte("key"), make([]byte, 9000), 0); !errors.As(err, &dataLimit) || dataLimit.Size != 9000 || dataLimit.Limit != 8128 || !errors.Is(err, ErrDataLimit) {
+		t.Errorf("Expecting error 'DataLimitError'")
+	}
+
+	var fullMemory *FullMemoryError
+	cache.Set([]byte("key1"), []byte("data"), 0)
+	if err := cache.Set([]byte("key2"), []byte("data"), 0); !errors.As(err, &fullMemory) || fullMemory.BufferCap != 0 || !errors.Is(err, ErrFullMemory) {
+		t.Errorf("Expecting error 'FullMemoryError'")
+	}
+}
@@ -0,0 +1,70 @@
+package atomiccache
+
+import (
+	"sync"
+)
+
+// Cache event types.
+const (
+	EventSet     = "set"
+	EventGetHit  = "get.hit"
+	EventGetMiss = "get.miss"
+	EventDelete  = "delete"
+	EventEvict   = "evict"
+	EventExpire  = "expire"
+)
+
+// CacheEvent describes one cache operation. Section is shard section ID of the
+// record (0 if it is not known) and Err is error returned by the operation.
+type CacheEvent struct {
+	Type    string
+	Key     []byte
+	Section uint8
+	Err     error
+}
+
+// EventBus receives cache events. Emit is called synchronously and eviction
+// events are emitted while the cache lock is held, so it has to be fast and it
+// must not call the cache.
+type EventBus interface {
+	Emit(event CacheEvent)
+}
+
+// RecordingEventBus is event bus which stores all events in memory. It is
+// intended for tests, which can assert on emitted events instead of internal
+// cache state. It is thread safe.
+type RecordingEventBus struct {
+	sync.Mutex
+	events []CacheEvent
+}
+
+// Emit stores event.
+func (r *RecordingEventBus) Emit(event CacheEvent) {
+	r.Lock()
+	r.events = append(r.events, event)
+	r.Unlock()
+}
+
+// Events returns copy of all stored events in emission order.
+func (r *RecordingEventBus) Events() []CacheEvent {
+	r.Lock()
+	defer r.Unlock()
+
+	return append([]CacheEvent(nil), r.events...)
+}
+
+// Reset removes all stored events.
+func (r *RecordingEventBus) Reset() {
+	r.Lock()
+	r.events = nil
+	r.Unlock()
+}
+
+// emitEvent sends event with copy of key to event bus (if it is set).
+func (a *AtomicCache) emitEvent(eventType string, key []byte, section uint8, err error) {
+	if a.eventBus == nil {
+		return
+	}
+
+	a.eventBus.Emit(CacheEvent{Type: eventType, Key: append([]byte(nil), key...), Section: section, Err: err})
+}
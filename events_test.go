@@ -0,0 +1,37 @@
+package atomiccache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCacheEventBus(t *testing.T) {
+	bus := &RecordingEventBus{}
+	cache := New(OptionEventBus(bus))
+
+	cache.Set([]byte("key"), []byte("data"), 0)
+	cache.Get([]byte("key"))
+	cache.Get([]byte("missing"))
+	cache.Delete([]byte("key"))
+	cache.Set([]byte("expired"), []byte("data"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	cache.CollectGarbage()
+
+	expected := []CacheEvent{
+		{Type: EventSet, Key: []byte("key"), Section: SMSH},
+		{Type: EventGetHit, Key: []byte("key"), Section: SMSH},
+		{Type: EventGetMiss, Key: []byte("missing"), Err: ErrNotFound},
+		{Type: EventDelete, Key: []byte("key"), Section: SMSH},
+		{Type: EventSet, Key: []byte("expired"), Section: SMSH},
+		{Type: EventEvict, Key: []byte("expired"), Section: SMSH},
+	}
+	if events := bus.Events(); !reflect.DeepEqual(events, expected) {
+		t.Errorf("%v != %v", events, expected)
+	}
+
+	bus.Reset()
+	if events := bus.Events(); len(events) != 0 {
+		t.Errorf("%v != %v", len(events), 0)
+	}
+}
@@ -0,0 +1,226 @@
+package atomiccache
+
+import (
+	"math/rand"
+	"time"
+)
+
+// evictionSamples is number of records sampled by eviction policies. Policies
+// select the record from random sample instead of whole section, so eviction
+// does not depend on size of cache memory.
+const evictionSamples = 16
+
+// EvictionPolicy selects record which is removed from full shards section, so
+// a new record can be stored. It returns shard index, record index and key of
+// selected record. If no record should be evicted, empty key is returned.
+type EvictionPolicy interface {
+	SelectEviction(section *ShardsLookup, lookup LookupBackend) (shardIdx, recordIdx uint32, key string)
+}
+
+// ID returns identifier of shards section (SMSH, MDSH, LGSH or custom tier).
+func (s *ShardsLookup) ID() uint8 {
+	return s.id
+}
+
+// NoEviction policy never evicts records. New records are stored to buffer
+// until garbage collector frees some space.
+type NoEviction struct{}
+
+// SelectEviction returns empty key.
+func (NoEviction) SelectEviction(section *ShardsLookup, lookup LookupBackend) (uint32, uint32, string) {
+	return 0, 0, ""
+}
+
+// LRUPolicy evicts least recently used record of random sample (approximated
+// LRU).
+type LRUPolicy struct{}
+
+// SelectEviction returns sampled record with the oldest last access time.
+func (LRUPolicy) SelectEviction(section *ShardsLookup, lookup LookupBackend) (uint32, uint32, string) {
+	return selectEviction(section, lookup, func(a, b LookupRecord) bool {
+		return a.LastAccess.Before(b.LastAccess)
+	})
+}
+
+// LFUPolicy evicts least frequently used record of random sample
+// (approximated LFU).
+type LFUPolicy struct{}
+
+// SelectEviction returns sampled record with the lowest access count.
+func (LFUPolicy) SelectEviction(section *ShardsLookup, lookup LookupBackend) (uint32, uint32, string) {
+	return selectEviction(section, lookup, func(a, b LookupRecord) bool {
+		return a.AccessCount < b.AccessCount
+	})
+}
+
+// FIFOPolicy evicts the oldest record of random sample (approximated FIFO).
+type FIFOPolicy struct{}
+
+// SelectEviction returns sampled record with the oldest creation time.
+func (FIFOPolicy) SelectEviction(section *ShardsLookup, lookup LookupBackend) (uint32, uint32, string) {
+	return selectEviction(section, lookup, func(a, b LookupRecord) bool {
+		return a.Created.Before(b.Created)
+	})
+}
+
+// RandomPolicy evicts random record.
+type RandomPolicy struct{}
+
+// SelectEviction returns random record of section.
+func (RandomPolicy) SelectEviction(section *ShardsLookup, lookup LookupBackend) (uint32, uint32, string) {
+	var selected LookupRecord
+	var selectedKey string
+
+	section.sample(lookup, 1, func(key string, val LookupRecord) bool {
+		selected, selectedKey = val, key
+		return false
+	})
+
+	return selected.ShardIndex, selected.RecordIndex, selectedKey
+}
+
+// selectEviction returns sampled record of section which is the first one
+// according to less function. Expired records are always selected first.
+func selectEviction(section *ShardsLookup, lookup LookupBackend, less func(a, b LookupRecord) bool) (uint32, uint32, string) {
+	var selected LookupRecord
+	var selectedKey string
+
+	now := section.now()
+	section.sample(lookup, evictionSamples, func(key string, val LookupRecord) bool {
+		if !isValid(val.Expiration, now) {
+			selected, selectedKey = val, key
+			return false
+		}
+		if selectedKey == "" || less(val, selected) {
+			selected, selectedKey = val, key
+		}
+		return true
+	})
+
+	return selected.ShardIndex, selected.RecordIndex, selectedKey
+}
+
+// sample calls function for up to n records of section which are selected
+// randomly from its slots (all records are visited in random order if section
+// has at most n slots). Lookup records contain current access statistics.
+// Sampling stops if function returns false. Cache memory has to be locked for
+// writing.
+func (s *ShardsLookup) sample(lookup LookupBackend, n int, fn func(key string, val LookupRecord) bool) {
+	if len(s.shardsActive) == 0 || s.slotCount == 0 {
+		return
+	}
+
+	slots := len(s.shardsActive) * int(s.slotCount)
+	if slots <= n {
+		for _, i := range rand.Perm(slots) {
+			if val, key, ok := s.slot(lookup, s.shardsActive[i/int(s.slotCount)], uint32(i%int(s.slotCount))); ok && !fn(key, val) {
+				return
+			}
+		}
+		return
+	}
+
+	// Free slots are skipped, so the number of attempts is limited in case
+	// section is sparse.
+	for attempts := 0; n > 0 && attempts < 4*n; attempts++ {
+		si := s.shardsActive[rand.Intn(len(s.shardsActive))]
+		if val, key, ok := s.slot(lookup, si, uint32(rand.Intn(int(s.slotCount)))); ok {
+			n--
+			if !fn(key, val) {
+				return
+			}
+		}
+	}
+}
+
+// slot returns lookup record and key of record stored in slot. Third value is
+// false if slot is free.
+func (s *ShardsLookup) slot(lookup LookupBackend, shardIndex, recordIndex uint32) (LookupRecord, string, bool) {
+	shard := s.shards[shardIndex]
+	if shard == nil {
+		return LookupRecord{}, "", false
+	}
+
+	record := shard.slots[recordIndex]
+	if record.key == "" {
+		return LookupRecord{}, "", false
+	}
+
+	val, ok := lookup.Get(record.key)
+	if !ok || val.ShardSection != s.id || val.ShardIndex != shardIndex || val.RecordIndex != recordIndex {
+		return LookupRecord{}, "", false
+	}
+	val.LastAccess, val.AccessCount = record.access()
+
+	return val, record.key, true
+}
+
+// now returns current time of section clock.
+func (s *ShardsLookup) now() time.Time {
+	if s.clock == nil {
+		return RealClock{}.Now()
+	}
+
+	return s.clock.Now()
+}
+
+// evictLocked removes record selected by eviction policy from shards section.
+// It returns true if some record was evicted.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) evictLocked(shardSectionID uint8) bool {
+	if a.evictionPolicy == nil {
+		return false
+	}
+
+	_, _, key := a.evictionPolicy.SelectEviction(a.getShardsSectionByID(shardSectionID), a.lookup)
+	if key == "" {
+		return false
+	}
+
+	val, ok := a.lookup.Get(key)
+	if !ok {
+		return false
+	}
+
+	a.freeLocked(key, val)
+	a.watchLocked(EventEvict, key, nil)
+	a.stats.evictions.Add(1)
+	a.metrics.RecordEviction()
+	a.traceOp("evict", []byte(key), nil, val)
+	a.emitEvent(EventEvict, []byte(key), val.ShardSection, nil)
+
+	return true
+}
+
+// accessLocked updates access statistics of record (used by eviction policies
+// and tier promotion). Statistics are updated atomically, so read lock which
+// is held by the caller for lookup of the record is enough and concurrent
+// reads are not serialized. It returns true if record should be promoted,
+// which is tried every TierPromotionThreshold+1 accesses.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) accessLocked(val LookupRecord) bool {
+	if a.evictionPolicy == nil && !a.tierPromotion {
+		return false
+	}
+
+	record := a.slotLocked(val)
+	if record == nil {
+		return false
+	}
+	count := record.touch(a.clock.Now())
+
+	return a.tierPromotion && count > a.tierPromotionThreshold && (count-a.tierPromotionThreshold-1)%(a.tierPromotionThreshold+1) == 0
+}
+
+// promote moves record to the smallest section where it fits (see
+// TierPromotion) under write lock.
+func (a *AtomicCache) promote(key []byte) {
+	a.Lock()
+	if val, ok := a.lookup.Get(string(key)); ok {
+		val = a.withAccessLocked(val)
+		if promoted := a.promoteLocked(val); promoted != val {
+			a.putLocked(string(key), promoted)
+		}
+	}
+	a.Unlock()
+}
@@ -0,0 +1,106 @@
+package atomiccache
+
+import (
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestEvictionPolicies(t *testing.T) {
+	for _, c := range []struct {
+		policy  EvictionPolicy
+		evicted string
+	}{
+		{LRUPolicy{}, "1"}, {LFUPolicy{}, "1"}, {FIFOPolicy{}, "0"},
+	} {
+		cache := New(OptionMaxRecords(2), OptionMaxShardsSmall(1), OptionEvictionPolicy(c.policy))
+		cache.Set([]byte("0"), []byte("data"), 0)
+		time.Sleep(time.Millisecond)
+		cache.Set([]byte("1"), []byte("data"), 0)
+		time.Sleep(time.Millisecond)
+		cache.Get([]byte("0"))
+
+		if err := cache.Set([]byte("2"), []byte("data"), 0); err != nil {
+			t.Errorf("%T: Set error: %s", c.policy, err.Error())
+		}
+		if cache.Exists([]byte(c.evicted)) {
+			t.Errorf("%T: %v was not evicted", c.policy, c.evicted)
+		}
+		if !cache.Exists([]byte("2")) {
+			t.Errorf("%T: new record was not stored", c.policy)
+		}
+	}
+}
+
+func TestEvictionRandomPolicy(t *testing.T) {
+	cache := New(OptionMaxRecords(4), OptionMaxShardsSmall(1), OptionEvictionPolicy(RandomPolicy{}))
+	for i := 0; i < 8; i++ {
+		cache.Set([]byte(strconv.Itoa(i)), []byte("data"), 0)
+	}
+
+	if items := cache.Stats().Items; items != 4 {
+		t.Errorf("%v != %v", items, 4)
+	}
+	if evictions := cache.Stats().Evictions; evictions != 4 {
+		t.Errorf("%v != %v", evictions, 4)
+	}
+	if !cache.Exists([]byte("7")) {
+		t.Errorf("Last record was not stored")
+	}
+}
+
+func TestEvictionNoEviction(t *testing.T) {
+	cache := New(OptionMaxRecords(1), OptionMaxShardsSmall(1), OptionEvictionPolicy(NoEviction{}))
+	cache.Set([]byte("0"), []byte("data"), 0)
+	cache.Set([]byte("1"), []byte("data"), 0)
+
+	if !cache.Exists([]byte("0")) {
+		t.Errorf("Record was evicted")
+	}
+}
+
+func TestEvictionClock(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	cache := New(OptionMaxRecords(2), OptionMaxShardsSmall(1), OptionEvictionPolicy(LRUPolicy{}), OptionClock(clock))
+	cache.Set([]byte("0"), []byte("data"), time.Second)
+	clock.Advance(time.Millisecond)
+	cache.Set([]byte("1"), []byte("data"), 0)
+	clock.Advance(time.Millisecond)
+	cache.Get([]byte("0"))
+
+	// Record expired by cache clock is evicted first
+	clock.Advance(2 * time.Second)
+	cache.Set([]byte("2"), []byte("data"), 0)
+	if _, err := cache.GetMeta([]byte("0")); err != ErrNotFound {
+		t.Errorf("Expired record was not evicted")
+	}
+	if !cache.Exists([]byte("1")) {
+		t.Errorf("Valid record was evicted")
+	}
+}
+
+func TestEvictionSampling(t *testing.T) {
+	cache := New(OptionMaxRecords(64), OptionMaxShardsSmall(4), OptionEvictionPolicy(LFUPolicy{}))
+	for i := 0; i < 256; i++ {
+		cache.Set([]byte(strconv.Itoa(i)), []byte("data"), 0)
+	}
+	for i := 0; i < 256; i++ {
+		cache.Get([]byte(strconv.Itoa(i)))
+	}
+
+	for i := 256; i < 512; i++ {
+		if err := cache.Set([]byte(strconv.Itoa(i)), []byte("data"), 0); err != nil {
+			t.Fatalf("Set error: %s", err.Error())
+		}
+	}
+
+	if items := cache.Stats().Items; items != 256 {
+		t.Errorf("%v != %v", items, 256)
+	}
+	if !cache.Exists([]byte("511")) {
+		t.Errorf("Last record was not stored")
+	}
+	if meta, err := cache.GetMeta([]byte("511")); err != nil || meta.AccessCount != 0 {
+		t.Errorf("%v != %v", meta.AccessCount, 0)
+	}
+}
@@ -0,0 +1,90 @@
+package atomiccache
+
+import (
+	"context"
+	"sync"
+)
+
+// flightCall represents in-flight or finished call of flight group.
+type flightCall struct {
+	done chan struct{}
+	data []byte
+	err  error
+	// Call failed because context of the caller which executed it is done,
+	// so the result is not shared with other callers.
+	cancelled bool
+	// Function panicked, the panic value is raised again in waiters.
+	panicked bool
+	panicErr any
+}
+
+// flightGroup deduplicates concurrent calls with the same key. Only the first
+// call is executed and other callers wait for its result.
+type flightGroup struct {
+	sync.Mutex
+	calls map[string]*flightCall
+}
+
+// Do executes function on input with context of the caller and returns its
+// result. If there is in-flight call with the same key, it waits for it and
+// returns its result instead. Every caller stops waiting when its own context
+// is done. If the in-flight call fails because context of its caller is done,
+// the error is not shared, waiting callers elect new caller which executes the
+// function again.
+func (g *flightGroup) Do(ctx context.Context, key string, fn func(context.Context) ([]byte, error)) ([]byte, error) {
+	for {
+		g.Lock()
+		if g.calls == nil {
+			g.calls = make(map[string]*flightCall)
+		}
+		call, ok := g.calls[key]
+		if !ok {
+			call = &flightCall{done: make(chan struct{})}
+			g.calls[key] = call
+			g.Unlock()
+
+			g.call(ctx, key, call, fn)
+
+			return call.data, call.err
+		}
+		g.Unlock()
+
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		if call.panicked {
+			panic(call.panicErr)
+		}
+		if !call.cancelled {
+			return call.data, call.err
+		}
+	}
+}
+
+// call executes function of flight call and releases its waiters. The call is
+// removed and waiters are released even if the function panics, the panic is
+// passed to the waiters and raised again.
+func (g *flightGroup) call(ctx context.Context, key string, call *flightCall, fn func(context.Context) ([]byte, error)) {
+	call.panicked = true
+	defer func() {
+		if call.panicked {
+			call.panicErr = recover()
+		}
+
+		g.Lock()
+		delete(g.calls, key)
+		g.Unlock()
+		close(call.done)
+
+		if call.panicked {
+			panic(call.panicErr)
+		}
+	}()
+
+	call.data, call.err = fn(ctx)
+	call.cancelled = call.err != nil && ctx.Err() != nil
+	call.panicked = false
+}
@@ -0,0 +1,119 @@
+package atomiccache
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestFlightGroupDeduplication(t *testing.T) {
+	var group flightGroup
+	var calls int32
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			group.Do(context.Background(), "key", func(context.Context) ([]byte, error) {
+				atomic.AddInt32(&calls, 1)
+				time.Sleep(50 * time.Millisecond)
+				return []byte("data"), nil
+			})
+		}()
+	}
+	wg.Wait()
+
+	if calls != 1 {
+		t.Errorf("%v != %v", calls, 1)
+	}
+}
+
+func TestFlightGroupContext(t *testing.T) {
+	var group flightGroup
+
+	started := make(chan struct{})
+	leaderCtx, cancelLeader := context.WithCancel(context.Background())
+	leaderErr := make(chan error)
+	go func() {
+		_, err := group.Do(leaderCtx, "key", func(ctx context.Context) ([]byte, error) {
+			close(started)
+			<-ctx.Done()
+			return nil, ctx.Err()
+		})
+		leaderErr <- err
+	}()
+	<-started
+
+	// Waiter stops waiting when its own context is done
+	waiterCtx, cancelWaiter := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancelWaiter()
+	if _, err := group.Do(waiterCtx, "key", func(context.Context) ([]byte, error) { return []byte("data"), nil }); err != context.DeadlineExceeded {
+		t.Errorf("%v != %v", err, context.DeadlineExceeded)
+	}
+
+	// Cancellation of leader is not shared, waiter calls the function again
+	result := make(chan []byte)
+	go func() {
+		data, _ := group.Do(context.Background(), "key", func(context.Context) ([]byte, error) {
+			return []byte("data"), nil
+		})
+		result <- data
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cancelLeader()
+
+	if err := <-leaderErr; err != context.Canceled {
+		t.Errorf("%v != %v", err, context.Canceled)
+	}
+	if data := <-result; string(data) != "data" {
+		t.Errorf("%q != %q", data, "data")
+	}
+}
+
+func TestFlightGroupPanic(t *testing.T) {
+	var group flightGroup
+
+	started := make(chan struct{})
+	release := make(chan struct{})
+	waiterPanic := make(chan any)
+
+	go func() {
+		defer func() { recover() }()
+		group.Do(context.Background(), "key", func(context.Context) ([]byte, error) {
+			close(started)
+			<-release
+			panic("loader failed")
+		})
+	}()
+	<-started
+
+	go func() {
+		defer func() { waiterPanic <- recover() }()
+		group.Do(context.Background(), "key", func(context.Context) ([]byte, error) { return nil, nil })
+	}()
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+
+	if value := <-waiterPanic; value != "loader failed" {
+		t.Errorf("%v != %v", value, "loader failed")
+	}
+
+	// Later callers are not blocked by panicked call
+	done := make(chan []byte)
+	go func() {
+		data, _ := group.Do(context.Background(), "key", func(context.Context) ([]byte, error) { return []byte("data"), nil })
+		done <- data
+	}()
+
+	select {
+	case data := <-done:
+		if string(data) != "data" {
+			t.Errorf("%q != %q", data, "data")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("Call is blocked after panic")
+	}
+}
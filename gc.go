@@ -0,0 +1,162 @@
+package atomiccache
+
+import (
+	"sync/atomic"
+)
+
+// Bounds of garbage collector starter in adaptive mode.
+const (
+	// MinGcStarter is the lowest starter value set by adaptive mode.
+	MinGcStarter = 100
+	// MaxGcStarter is the highest starter value set by adaptive mode.
+	MaxGcStarter = 100_000
+)
+
+// gcTrigger contains garbage collector starter and counter. Both values are
+// accessed only through sync/atomic, so they can be read and updated without
+// the cache lock. Atomic operations are sequentially consistent, but they do
+// not order any other cache memory access. The structure is padded to its own
+// cache line, so frequent counter updates do not cause false sharing with
+// neighbouring cache fields.
+type gcTrigger struct {
+	_ [64]byte
+	// Garbage collector starter (run garbage collection every X memory sets).
+	starter atomic.Uint32
+	// Garbage collector counter for starter.
+	counter atomic.Uint32
+	// Number of running garbage collections.
+	running atomic.Int32
+	// Garbage collection was requested while another one was running.
+	pending atomic.Bool
+	_       [48]byte
+}
+
+// tick increments counter and returns true if counter reached starter value.
+func (g *gcTrigger) tick() bool {
+	return g.counter.Add(1) >= g.starter.Load()
+}
+
+// GcStarter returns number of memory sets after which garbage collection is
+// started.
+func (a *AtomicCache) GcStarter() uint32 {
+	return a.gc.starter.Load()
+}
+
+// GcCounter returns number of memory sets since the last garbage collection.
+func (a *AtomicCache) GcCounter() uint32 {
+	return a.gc.counter.Load()
+}
+
+// SetGCStarter sets number of memory sets after which garbage collection is
+// started. It can be called at runtime (e.g. on configuration reload) without
+// any lock. If adaptive garbage collection is enabled, the starter is adjusted
+// from the new value.
+func (a *AtomicCache) SetGCStarter(n uint32) {
+	a.gc.starter.Store(n)
+}
+
+// ResetGCCounter resets number of memory sets since the last garbage
+// collection, so the next garbage collection is postponed by full starter
+// period.
+func (a *AtomicCache) ResetGCCounter() {
+	a.gc.counter.Store(0)
+}
+
+// startGarbageCollection runs garbage collection in new goroutine. Running
+// garbage collection can be awaited by WaitForGC. At most one garbage
+// collection runs at a time (guarded by gcSem). If another one is running, the
+// goroutine exits immediately and the running one repeats the collection
+// after it finishes, so no request is lost.
+func (a *AtomicCache) startGarbageCollection() {
+	a.gc.running.Add(1)
+	go func() {
+		defer a.finishGarbageCollection()
+
+		a.gc.pending.Store(true)
+		for a.gc.pending.Load() {
+			select {
+			case a.gcSem <- struct{}{}:
+			default:
+				return
+			}
+			a.gc.pending.Store(false)
+			a.collectGarbage()
+			<-a.gcSem
+		}
+	}()
+}
+
+// finishGarbageCollection decrements number of running garbage collections
+// and wakes up WaitForGC callers if it was the last one. Both are done under
+// the wait mutex, so the wake-up cannot be lost.
+func (a *AtomicCache) finishGarbageCollection() {
+	a.gcWaitMutex.Lock()
+	if a.gc.running.Add(-1) == 0 {
+		a.gcWaitCond.Broadcast()
+	}
+	a.gcWaitMutex.Unlock()
+}
+
+// WaitForGC blocks until all running garbage collections are finished. If
+// there is no running garbage collection, it returns immediately. Garbage
+// collections can be started concurrently (e.g. by Set), they are awaited too.
+func (a *AtomicCache) WaitForGC() {
+	a.gcWaitMutex.Lock()
+	for a.gc.running.Load() != 0 {
+		a.gcWaitCond.Wait()
+	}
+	a.gcWaitMutex.Unlock()
+}
+
+// CollectGarbage runs garbage collection synchronously. Expired records are
+// removed and buffer of unattended set requests is processed.
+func (a *AtomicCache) CollectGarbage() {
+	a.gc.counter.Store(0)
+	a.gcSem <- struct{}{}
+	a.collectGarbage()
+	<-a.gcSem
+}
+
+// adapt adjusts starter according to eviction rate of the last garbage
+// collection. Starter is halved if more than half of scanned records were
+// evicted (cache is thrashing) and doubled if less than 1 % of them were
+// evicted (cache is stable). The result is clamped between MinGcStarter and
+// MaxGcStarter.
+func (g *gcTrigger) adapt(evicted, scanned int) {
+	var rate float64
+	if scanned > 0 {
+		rate = float64(evicted) / float64(scanned)
+	}
+
+	starter := uint64(g.starter.Load())
+	switch {
+	case rate > 0.5:
+		starter /= 2
+	case rate < 0.01:
+		starter *= 2
+	default:
+		return
+	}
+
+	if starter < MinGcStarter {
+		starter = MinGcStarter
+	} else if starter > MaxGcStarter {
+		starter = MaxGcStarter
+	}
+
+	g.starter.Store(uint32(starter))
+}
+
+// utilizationLocked returns ratio of used slots to all slots (including slots
+// of shards which are not allocated yet) of shard section.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) utilizationLocked(shardSectionID uint8) float64 {
+	shardSection := a.getShardsSectionByID(shardSectionID)
+
+	var used uint64
+	for _, shardIndex := range shardSection.shardsActive {
+		used += uint64(shardSection.slotCount - shardSection.shards[shardIndex].GetSlotsAvail())
+	}
+
+	return float64(used) / (float64(shardSection.maxShards) * float64(shardSection.slotCount))
+}
@@ -0,0 +1,201 @@
+package atomiccache
+
+import (
+	"testing"
+	"time"
+	"unsafe"
+)
+
+func TestGcTrigger(t *testing.T) {
+	cache := New(OptionGcStarter(3))
+	for i := 0; i < 2; i++ {
+		cache.Set([]byte("key"), []byte("data"), 0)
+	}
+
+	if cache.GcStarter() != 3 || cache.GcCounter() != 2 {
+		t.Errorf("Unexpected starter/counter: %d/%d", cache.GcStarter(), cache.GcCounter())
+	}
+
+	cache.Set([]byte("key"), []byte("data"), 0)
+	if cache.GcCounter() != 0 {
+		t.Errorf("%v != %v", cache.GcCounter(), 0)
+	}
+}
+
+func TestGcTriggerPadding(t *testing.T) {
+	var trigger gcTrigger
+	if offset := unsafe.Offsetof(trigger.starter); offset < 64 {
+		t.Errorf("Starter offset %v is not padded", offset)
+	}
+	if size := unsafe.Sizeof(trigger); size < 128 {
+		t.Errorf("Trigger size %v is not padded", size)
+	}
+}
+
+func TestWaitForGC(t *testing.T) {
+	cache := New(OptionMaxRecords(1), OptionMaxShardsSmall(1))
+
+	// Nothing is running, so it has to return immediately
+	cache.WaitForGC()
+
+	cache.Set([]byte("expired"), []byte("data"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+	cache.Set([]byte("key"), []byte("data"), 0)
+
+	cache.WaitForGC()
+	if !cache.Exists([]byte("key")) {
+		t.Errorf("Buffered record was not stored after garbage collection")
+	}
+	if cache.Exists([]byte("expired")) {
+		t.Errorf("Expired record was not collected")
+	}
+}
+
+func TestWaitForGCConcurrent(t *testing.T) {
+	cache := New(OptionGcStarter(1))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 1000; i++ {
+			cache.Set([]byte("key"), []byte("data"), 0)
+		}
+	}()
+
+	for {
+		select {
+		case <-done:
+			cache.WaitForGC()
+			if running := cache.gc.running.Load(); running != 0 {
+				t.Errorf("%v != %v", running, 0)
+			}
+			return
+		default:
+			cache.WaitForGC()
+		}
+	}
+}
+
+func TestGcUtilizationThreshold(t *testing.T) {
+	cache := New(OptionMaxRecords(2), OptionMaxShardsSmall(1), OptionGcUtilizationThreshold(0.6))
+
+	cache.Set([]byte("expired"), []byte("data"), time.Millisecond)
+	cache.WaitForGC()
+	if runs := cache.Stats().GcRuns; runs != 0 {
+		t.Errorf("%v != %v", runs, 0)
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	cache.Set([]byte("key"), []byte("data"), 0)
+	cache.WaitForGC()
+	if runs := cache.Stats().GcRuns; runs != 1 {
+		t.Errorf("%v != %v", runs, 1)
+	}
+	if items := cache.Stats().Items; items != 1 {
+		t.Errorf("%v != %v", items, 1)
+	}
+}
+
+func TestGcExpirationNotify(t *testing.T) {
+	notify := make(chan []byte, 1)
+	cache := New(OptionExpirationNotify(notify))
+
+	cache.Set([]byte("key1"), []byte("data"), time.Millisecond)
+	cache.Set([]byte("key2"), []byte("data"), time.Millisecond)
+	cache.Set([]byte("key3"), []byte("data"), time.Hour)
+	cache.Set([]byte("key4"), []byte("data"), time.Millisecond)
+	cache.Delete([]byte("key4"))
+	time.Sleep(5 * time.Millisecond)
+
+	cache.CollectGarbage()
+
+	select {
+	case key := <-notify:
+		if string(key) != "key1" && string(key) != "key2" {
+			t.Errorf("Unexpected key %q", key)
+		}
+	default:
+		t.Errorf("Expiration is not notified")
+	}
+
+	if len(notify) != 0 {
+		t.Errorf("%v != %v", len(notify), 0)
+	}
+}
+
+func TestGcTriggerAdapt(t *testing.T) {
+	var trigger gcTrigger
+
+	for i, c := range []struct {
+		starter, want    uint32
+		evicted, scanned int
+	}{
+		{1000, 500, 60, 100},
+		{1000, 2000, 0, 100},
+		{1000, 1000, 10, 100},
+		{150, MinGcStarter, 100, 100},
+		{80000, MaxGcStarter, 0, 0},
+	} {
+		trigger.starter.Store(c.starter)
+		trigger.adapt(c.evicted, c.scanned)
+		if starter := trigger.starter.Load(); starter != c.want {
+			t.Errorf("[%d] %v != %v", i, starter, c.want)
+		}
+	}
+}
+
+func TestGcAdaptiveStarter(t *testing.T) {
+	cache := New(OptionAdaptiveGC(true), OptionGcStarter(1000))
+	cache.Set([]byte("key"), []byte("data"), time.Hour)
+
+	cache.CollectGarbage()
+	if cache.GcStarter() != 2000 {
+		t.Errorf("%v != %v", cache.GcStarter(), 2000)
+	}
+}
+
+func TestGcSemaphore(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("data"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	// Simulate running garbage collection
+	cache.gcSem <- struct{}{}
+	cache.startGarbageCollection()
+	cache.WaitForGC()
+
+	if items := cache.Stats().Items; items != 1 {
+		t.Errorf("%v != %v", items, 1)
+	}
+	if !cache.gc.pending.Load() {
+		t.Errorf("Skipped garbage collection is not pending")
+	}
+
+	<-cache.gcSem
+	cache.startGarbageCollection()
+	cache.WaitForGC()
+
+	if items := cache.Stats().Items; items != 0 {
+		t.Errorf("%v != %v", items, 0)
+	}
+}
+
+func TestGcStarterRuntime(t *testing.T) {
+	cache := New(OptionGcStarter(1000))
+	cache.Set([]byte("key1"), []byte("data"), 0)
+	cache.Set([]byte("key2"), []byte("data"), 0)
+
+	if cache.GcCounter() != 2 {
+		t.Errorf("%v != %v", cache.GcCounter(), 2)
+	}
+
+	cache.SetGCStarter(10)
+	cache.ResetGCCounter()
+
+	if cache.GcStarter() != 10 {
+		t.Errorf("%v != %v", cache.GcStarter(), 10)
+	}
+	if cache.GcCounter() != 0 {
+		t.Errorf("%v != %v", cache.GcCounter(), 0)
+	}
+}
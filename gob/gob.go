@@ -0,0 +1,70 @@
+// Package gob provides helpers which store Go values to atomic cache encoded
+// by encoding/gob. Gob is faster than JSON for known types and it does not
+// require struct tags. It is a separate package, so the core cache does not
+// depend on any encoding.
+package gob
+
+import (
+	"bytes"
+	stdgob "encoding/gob"
+	"sync"
+	"time"
+
+	atomiccache "github.com/PraserX/atomic-cache"
+)
+
+// buffers is pool of encoding buffers shared by all encoders.
+var buffers = sync.Pool{
+	New: func() any {
+		return new(bytes.Buffer)
+	},
+}
+
+// GobEncoder stores values to cache encoded by gob. Types stored in interface
+// values have to be registered, NewGobEncoder registers them in advance.
+type GobEncoder struct {
+	cache atomiccache.Cache
+}
+
+// NewGobEncoder returns encoder for cache on input. Types on input are
+// registered by gob.Register.
+func NewGobEncoder(c atomiccache.Cache, types ...any) *GobEncoder {
+	for _, t := range types {
+		stdgob.Register(t)
+	}
+
+	return &GobEncoder{cache: c}
+}
+
+// SetGob encodes value and stores it to cache under key.
+func (e *GobEncoder) SetGob(key []byte, v any, expire time.Duration) error {
+	buf := buffers.Get().(*bytes.Buffer)
+	defer buffers.Put(buf)
+	buf.Reset()
+
+	if err := stdgob.NewEncoder(buf).Encode(v); err != nil {
+		return err
+	}
+
+	return e.cache.Set(key, buf.Bytes(), expire)
+}
+
+// GetGob reads record of key and decodes it to value (pointer).
+func (e *GobEncoder) GetGob(key []byte, v any) error {
+	data, err := e.cache.Get(key)
+	if err != nil {
+		return err
+	}
+
+	return stdgob.NewDecoder(bytes.NewReader(data)).Decode(v)
+}
+
+// SetGob encodes value and stores it to cache under key.
+func SetGob(c atomiccache.Cache, key []byte, v any, expire time.Duration) error {
+	return NewGobEncoder(c).SetGob(key, v, expire)
+}
+
+// GetGob reads record of key from cache and decodes it to value (pointer).
+func GetGob(c atomiccache.Cache, key []byte, v any) error {
+	return NewGobEncoder(c).GetGob(key, v)
+}
@@ -0,0 +1,47 @@
+package gob
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	atomiccache "github.com/PraserX/atomic-cache"
+)
+
+type user struct {
+	Name string
+	Age  int
+}
+
+type shape interface{}
+
+func TestGob(t *testing.T) {
+	cache := atomiccache.New()
+
+	if err := SetGob(cache, []byte("user"), user{Name: "Alice", Age: 30}, 0); err != nil {
+		t.Errorf("SetGob error: %s", err.Error())
+	}
+
+	var got user
+	if err := GetGob(cache, []byte("user"), &got); err != nil || !reflect.DeepEqual(got, user{Name: "Alice", Age: 30}) {
+		t.Errorf("%v != %v", got, user{Name: "Alice", Age: 30})
+	}
+
+	if err := GetGob(cache, []byte("missing"), &got); !errors.Is(err, atomiccache.ErrNotFound) {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestGobEncoderRegister(t *testing.T) {
+	encoder := NewGobEncoder(atomiccache.New(), user{})
+
+	var in shape = user{Name: "Bob"}
+	if err := encoder.SetGob([]byte("shape"), &in, 0); err != nil {
+		t.Errorf("SetGob error: %s", err.Error())
+	}
+
+	var out shape
+	if err := encoder.GetGob([]byte("shape"), &out); err != nil || !reflect.DeepEqual(out, in) {
+		t.Errorf("%v != %v", out, in)
+	}
+}
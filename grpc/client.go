@@ -0,0 +1,186 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	atomiccache "github.com/PraserX/atomic-cache"
+	grpcapi "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// Item is record stored by MSet.
+type Item struct {
+	Key    []byte
+	Data   []byte
+	Expire time.Duration
+}
+
+// Client is gRPC client of cache service. It implements atomiccache.Cache
+// interface, so it can replace in-process cache.
+type Client struct {
+	conn *grpcapi.ClientConn
+}
+
+var _ atomiccache.Cache = (*Client)(nil)
+
+// NewGRPCClient returns client connected to cache service on address. If no
+// dial options are specified, insecure connection (without TLS) is used.
+func NewGRPCClient(addr string, opts ...grpcapi.DialOption) (*Client, error) {
+	if len(opts) == 0 {
+		opts = []grpcapi.DialOption{grpcapi.WithTransportCredentials(insecure.NewCredentials())}
+	}
+	opts = append(opts, grpcapi.WithDefaultCallOptions(grpcapi.CallContentSubtype(codecName)))
+
+	conn, err := grpcapi.NewClient(addr, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Client{conn: conn}, nil
+}
+
+// invoke calls unary method of cache service.
+func (c *Client) invoke(method string, req, resp any) error {
+	return fromStatus(c.conn.Invoke(context.Background(), "/"+ServiceName+"/"+method, req, resp))
+}
+
+// Set store data to remote cache memory.
+func (c *Client) Set(key []byte, data []byte, expire time.Duration) error {
+	return c.invoke("Set", &SetRequest{Key: key, Data: data, Expire: expire}, &Empty{})
+}
+
+// Get returns stored record from remote cache memory.
+func (c *Client) Get(key []byte) ([]byte, error) {
+	var resp ValueResponse
+	if err := c.invoke("Get", &KeyRequest{Key: key}, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+// Delete removes record from remote cache memory.
+func (c *Client) Delete(key []byte) error {
+	return c.invoke("Delete", &KeyRequest{Key: key}, &Empty{})
+}
+
+// Exists returns true if record is present in remote cache memory.
+func (c *Client) Exists(key []byte) bool {
+	_, err := c.TTL(key)
+	return err == nil
+}
+
+// Expire sets new expiration time of record in remote cache memory.
+func (c *Client) Expire(key []byte, expire time.Duration) error {
+	return c.invoke("Expire", &ExpireRequest{Key: key, Expire: expire}, &Empty{})
+}
+
+// TTL returns remaining time to live of record in remote cache memory.
+func (c *Client) TTL(key []byte) (time.Duration, error) {
+	var resp TTLResponse
+	if err := c.invoke("TTL", &KeyRequest{Key: key}, &resp); err != nil {
+		return 0, err
+	}
+	return resp.TTL, nil
+}
+
+// GetOrSet returns data of record if it is present in remote cache memory.
+// Otherwise function on input is called and its result is stored. Unlike
+// in-process cache, concurrent calls are not deduplicated.
+func (c *Client) GetOrSet(key []byte, fn func() ([]byte, error), expire time.Duration) ([]byte, error) {
+	if data, err := c.Get(key); err == nil || !errors.Is(err, atomiccache.ErrNotFound) {
+		return data, err
+	}
+
+	data, err := fn()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.Set(key, data, expire); err != nil {
+		return nil, err
+	}
+
+	return data, nil
+}
+
+// Scan returns keys with specified prefix from remote cache memory. If call
+// fails, nil is returned.
+func (c *Client) Scan(prefix string) [][]byte {
+	var resp KeysResponse
+	if err := c.invoke("Scan", &ScanRequest{Prefix: prefix}, &resp); err != nil {
+		return nil
+	}
+	return resp.Keys
+}
+
+// Flush removes all records from remote cache memory.
+func (c *Client) Flush() error {
+	return c.invoke("Flush", &Empty{}, &Empty{})
+}
+
+// Close closes connection. Remote cache memory is not affected.
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// MGet returns data of multiple records using single stream. Result has the
+// same order as keys on input and missing records are nil.
+func (c *Client) MGet(keys [][]byte) ([][]byte, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := c.conn.NewStream(ctx, &serviceDesc.Streams[0], "/"+ServiceName+"/MGet")
+	if err != nil {
+		return nil, fromStatus(err)
+	}
+
+	// Keys are sent concurrently, so flow control cannot block the stream.
+	// Send errors are reported by RecvMsg.
+	go func() {
+		for _, key := range keys {
+			if err := stream.SendMsg(&KeyRequest{Key: key}); err != nil {
+				return
+			}
+		}
+		stream.CloseSend()
+	}()
+
+	result := make([][]byte, len(keys))
+	for i := range keys {
+		var resp ValueResponse
+		if err := stream.RecvMsg(&resp); err != nil {
+			return nil, fromStatus(err)
+		}
+		if resp.Found {
+			result[i] = resp.Data
+		}
+	}
+
+	return result, nil
+}
+
+// MSet stores multiple records using single stream. Storing stops at first
+// error, which is returned.
+func (c *Client) MSet(items []Item) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream, err := c.conn.NewStream(ctx, &serviceDesc.Streams[1], "/"+ServiceName+"/MSet")
+	if err != nil {
+		return fromStatus(err)
+	}
+
+	for _, item := range items {
+		if err := stream.SendMsg(&SetRequest{Key: item.Key, Data: item.Data, Expire: item.Expire}); err != nil {
+			break // error is returned by RecvMsg
+		}
+	}
+
+	if err := stream.CloseSend(); err != nil {
+		return fromStatus(err)
+	}
+
+	return fromStatus(stream.RecvMsg(&Empty{}))
+}
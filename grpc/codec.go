@@ -0,0 +1,81 @@
+// Package grpc exposes atomic cache over the network as gRPC service
+// (CacheService) with Set, Get, Delete, Expire, TTL, Scan, Flush, MGet and MSet
+// methods. Messages are plain Go structures encoded by JSON codec registered
+// under "atomiccache" content subtype, so no generated protobuf code is needed.
+// It is a separate package, so the core cache does not depend on gRPC.
+package grpc
+
+import (
+	"encoding/json"
+	"time"
+
+	"google.golang.org/grpc/encoding"
+)
+
+// codecName is name of codec and content subtype used by the service.
+const codecName = "atomiccache"
+
+func init() {
+	encoding.RegisterCodec(codec{})
+}
+
+// codec encodes service messages to JSON.
+type codec struct{}
+
+// Marshal returns JSON encoding of message.
+func (codec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+// Unmarshal decodes JSON encoded message.
+func (codec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+// Name returns name of codec.
+func (codec) Name() string {
+	return codecName
+}
+
+// SetRequest is used by Set and MSet methods.
+type SetRequest struct {
+	Key    []byte        `json:"key"`
+	Data   []byte        `json:"data"`
+	Expire time.Duration `json:"expire"`
+}
+
+// KeyRequest is used by methods which need only the key.
+type KeyRequest struct {
+	Key []byte `json:"key"`
+}
+
+// ExpireRequest is used by Expire method.
+type ExpireRequest struct {
+	Key    []byte        `json:"key"`
+	Expire time.Duration `json:"expire"`
+}
+
+// ScanRequest is used by Scan method.
+type ScanRequest struct {
+	Prefix string `json:"prefix"`
+}
+
+// ValueResponse is returned by Get and MGet methods. Found is false if MGet
+// does not find the key.
+type ValueResponse struct {
+	Data  []byte `json:"data"`
+	Found bool   `json:"found"`
+}
+
+// TTLResponse is returned by TTL method.
+type TTLResponse struct {
+	TTL time.Duration `json:"ttl"`
+}
+
+// KeysResponse is returned by Scan method.
+type KeysResponse struct {
+	Keys [][]byte `json:"keys"`
+}
+
+// Empty is used by methods without request or response data.
+type Empty struct{}
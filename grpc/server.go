@@ -0,0 +1,224 @@
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	atomiccache "github.com/PraserX/atomic-cache"
+	grpcapi "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ServiceName is full name of the cache service.
+const ServiceName = "atomiccache.CacheService"
+
+// knownErrors are cache errors, which are restored by client from status
+// message.
+var knownErrors = []error{
+	atomiccache.ErrNotFound,
+	atomiccache.ErrExpired,
+	atomiccache.ErrDataLimit,
+	atomiccache.ErrFullMemory,
+	atomiccache.ErrKeyTooLong,
+	atomiccache.ErrKeyTooShort,
+	atomiccache.ErrChecksumMismatch,
+}
+
+// NewGRPCServer returns gRPC server with registered cache service. Server
+// options (e.g. TLS credentials or interceptors) are passed to gRPC server.
+func NewGRPCServer(c atomiccache.Cache, opts ...grpcapi.ServerOption) *grpcapi.Server {
+	s := grpcapi.NewServer(opts...)
+	RegisterCacheService(s, c)
+	return s
+}
+
+// RegisterCacheService registers cache service to existing gRPC server.
+func RegisterCacheService(s grpcapi.ServiceRegistrar, c atomiccache.Cache) {
+	s.RegisterService(&serviceDesc, &cacheServer{cache: c})
+}
+
+// cacheServer implements cache service methods.
+type cacheServer struct {
+	cache atomiccache.Cache
+}
+
+func (s *cacheServer) set(ctx context.Context, req *SetRequest) (*Empty, error) {
+	if err := s.cache.Set(req.Key, req.Data, req.Expire); err != nil {
+		return nil, toStatus(err)
+	}
+	return &Empty{}, nil
+}
+
+func (s *cacheServer) get(ctx context.Context, req *KeyRequest) (*ValueResponse, error) {
+	data, err := s.cache.Get(req.Key)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &ValueResponse{Data: data, Found: true}, nil
+}
+
+func (s *cacheServer) delete(ctx context.Context, req *KeyRequest) (*Empty, error) {
+	if err := s.cache.Delete(req.Key); err != nil {
+		return nil, toStatus(err)
+	}
+	return &Empty{}, nil
+}
+
+func (s *cacheServer) expire(ctx context.Context, req *ExpireRequest) (*Empty, error) {
+	if err := s.cache.Expire(req.Key, req.Expire); err != nil {
+		return nil, toStatus(err)
+	}
+	return &Empty{}, nil
+}
+
+func (s *cacheServer) ttl(ctx context.Context, req *KeyRequest) (*TTLResponse, error) {
+	ttl, err := s.cache.TTL(req.Key)
+	if err != nil {
+		return nil, toStatus(err)
+	}
+	return &TTLResponse{TTL: ttl}, nil
+}
+
+func (s *cacheServer) scan(ctx context.Context, req *ScanRequest) (*KeysResponse, error) {
+	return &KeysResponse{Keys: s.cache.Scan(req.Prefix)}, nil
+}
+
+func (s *cacheServer) flush(ctx context.Context, req *Empty) (*Empty, error) {
+	if err := s.cache.Flush(); err != nil {
+		return nil, toStatus(err)
+	}
+	return &Empty{}, nil
+}
+
+// mget receives keys and sends value of every key back in the same order.
+func (s *cacheServer) mget(stream grpcapi.ServerStream) error {
+	for {
+		var req KeyRequest
+		if err := stream.RecvMsg(&req); err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		resp := &ValueResponse{}
+		if data, err := s.cache.Get(req.Key); err == nil {
+			resp.Data, resp.Found = data, true
+		}
+
+		if err := stream.SendMsg(resp); err != nil {
+			return err
+		}
+	}
+}
+
+// mset receives records and stores them. Receiving stops at first error.
+func (s *cacheServer) mset(stream grpcapi.ServerStream) error {
+	for {
+		var req SetRequest
+		if err := stream.RecvMsg(&req); err == io.EOF {
+			return stream.SendMsg(&Empty{})
+		} else if err != nil {
+			return err
+		}
+
+		if err := s.cache.Set(req.Key, req.Data, req.Expire); err != nil {
+			return toStatus(err)
+		}
+	}
+}
+
+// unaryMethod returns description of unary method, which decodes request and
+// calls method of cache server (through interceptor if it is set).
+func unaryMethod[Req, Resp any](name string, method func(*cacheServer, context.Context, *Req) (*Resp, error)) grpcapi.MethodDesc {
+	return grpcapi.MethodDesc{
+		MethodName: name,
+		Handler: func(srv any, ctx context.Context, dec func(any) error, interceptor grpcapi.UnaryServerInterceptor) (any, error) {
+			req := new(Req)
+			if err := dec(req); err != nil {
+				return nil, err
+			}
+
+			if interceptor == nil {
+				return method(srv.(*cacheServer), ctx, req)
+			}
+
+			info := &grpcapi.UnaryServerInfo{Server: srv, FullMethod: "/" + ServiceName + "/" + name}
+			return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+				return method(srv.(*cacheServer), ctx, req.(*Req))
+			})
+		},
+	}
+}
+
+// serviceDesc describes cache service.
+var serviceDesc = grpcapi.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*any)(nil),
+	Methods: []grpcapi.MethodDesc{
+		unaryMethod("Set", (*cacheServer).set),
+		unaryMethod("Get", (*cacheServer).get),
+		unaryMethod("Delete", (*cacheServer).delete),
+		unaryMethod("Expire", (*cacheServer).expire),
+		unaryMethod("TTL", (*cacheServer).ttl),
+		unaryMethod("Scan", (*cacheServer).scan),
+		unaryMethod("Flush", (*cacheServer).flush),
+	},
+	Streams: []grpcapi.StreamDesc{
+		{
+			StreamName: "MGet",
+			Handler: func(srv any, stream grpcapi.ServerStream) error {
+				return srv.(*cacheServer).mget(stream)
+			},
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName: "MSet",
+			Handler: func(srv any, stream grpcapi.ServerStream) error {
+				return srv.(*cacheServer).mset(stream)
+			},
+			ClientStreams: true,
+		},
+	},
+}
+
+// toStatus converts cache error to gRPC status error. Known cache errors are
+// sent with message of the base error (without context of structured error),
+// so client can restore them.
+func toStatus(err error) error {
+	code := codes.Internal
+	switch {
+	case errors.Is(err, atomiccache.ErrNotFound), errors.Is(err, atomiccache.ErrExpired):
+		code = codes.NotFound
+	case errors.Is(err, atomiccache.ErrFullMemory):
+		code = codes.ResourceExhausted
+	case errors.Is(err, atomiccache.ErrDataLimit), errors.Is(err, atomiccache.ErrKeyTooLong), errors.Is(err, atomiccache.ErrKeyTooShort):
+		code = codes.InvalidArgument
+	}
+
+	for _, known := range knownErrors {
+		if errors.Is(err, known) {
+			return status.Error(code, known.Error())
+		}
+	}
+
+	return status.Error(code, err.Error())
+}
+
+// fromStatus converts gRPC status error back to cache error if possible.
+func fromStatus(err error) error {
+	if err == nil {
+		return nil
+	}
+
+	message := status.Convert(err).Message()
+	for _, known := range knownErrors {
+		if message == known.Error() {
+			return known
+		}
+	}
+
+	return err
+}
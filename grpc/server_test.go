@@ -0,0 +1,97 @@
+package grpc
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+
+	atomiccache "github.com/PraserX/atomic-cache"
+)
+
+// testStream is in-memory server stream with prepared requests.
+type testStream struct {
+	requests  []any
+	responses []any
+}
+
+func (s *testStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *testStream) SendMsg(m any) error {
+	s.responses = append(s.responses, m)
+	return nil
+}
+
+func (s *testStream) RecvMsg(m any) error {
+	if len(s.requests) == 0 {
+		return io.EOF
+	}
+
+	reflect.ValueOf(m).Elem().Set(reflect.ValueOf(s.requests[0]).Elem())
+	s.requests = s.requests[1:]
+
+	return nil
+}
+
+func TestServerUnary(t *testing.T) {
+	server := &cacheServer{cache: atomiccache.New()}
+
+	if _, err := server.set(context.Background(), &SetRequest{Key: []byte("key"), Data: []byte("data")}); err != nil {
+		t.Errorf("Set error: %s", err.Error())
+	}
+	if resp, err := server.get(context.Background(), &KeyRequest{Key: []byte("key")}); err != nil || !reflect.DeepEqual(resp.Data, []byte("data")) {
+		t.Errorf("%v != %v", resp, []byte("data"))
+	}
+	if _, err := server.delete(context.Background(), &KeyRequest{Key: []byte("key")}); err != nil {
+		t.Errorf("Delete error: %s", err.Error())
+	}
+	if _, err := server.get(context.Background(), &KeyRequest{Key: []byte("key")}); fromStatus(err) != atomiccache.ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+}
+
+func TestServerStreams(t *testing.T) {
+	server := &cacheServer{cache: atomiccache.New()}
+
+	mset := &testStream{requests: []any{
+		&SetRequest{Key: []byte("key1"), Data: []byte("data1")},
+		&SetRequest{Key: []byte("key2"), Data: []byte("data2")},
+	}}
+	if err := server.mset(mset); err != nil {
+		t.Errorf("MSet error: %s", err.Error())
+	}
+
+	mget := &testStream{requests: []any{
+		&KeyRequest{Key: []byte("key1")},
+		&KeyRequest{Key: []byte("missing")},
+		&KeyRequest{Key: []byte("key2")},
+	}}
+	if err := server.mget(mget); err != nil {
+		t.Errorf("MGet error: %s", err.Error())
+	}
+
+	expected := []any{
+		&ValueResponse{Data: []byte("data1"), Found: true},
+		&ValueResponse{},
+		&ValueResponse{Data: []byte("data2"), Found: true},
+	}
+	if !reflect.DeepEqual(mget.responses, expected) {
+		t.Errorf("%v != %v", mget.responses, expected)
+	}
+}
+
+func TestCodec(t *testing.T) {
+	in := &SetRequest{Key: []byte("key"), Data: []byte{0, 1, 2}, Expire: 5}
+
+	data, err := codec{}.Marshal(in)
+	if err != nil {
+		t.Fatalf("Marshal error: %s", err.Error())
+	}
+
+	var out SetRequest
+	if err := (codec{}).Unmarshal(data, &out); err != nil || !reflect.DeepEqual(&out, in) {
+		t.Errorf("%v != %v", out, in)
+	}
+}
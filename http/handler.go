@@ -0,0 +1,148 @@
+// Package http provides HTTP handler for runtime inspection of atomic cache.
+// The handler has no authentication, it should be wrapped by standard
+// middleware if it is exposed outside of trusted network.
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	nethttp "net/http"
+	"strings"
+	"time"
+
+	atomiccache "github.com/PraserX/atomic-cache"
+)
+
+// keyPrefix is path prefix of single key endpoint.
+const keyPrefix = "/key/"
+
+// KeyInfo is response of GET /key/{k} endpoint. Value is encoded as base64.
+type KeyInfo struct {
+	Key         string `json:"key"`
+	Value       []byte `json:"value"`
+	TTL         string `json:"ttl"`
+	Section     string `json:"section"`
+	ShardIndex  uint32 `json:"shard_index"`
+	RecordIndex uint32 `json:"record_index"`
+}
+
+// handler serves inspection endpoints of cache.
+type handler struct {
+	cache *atomiccache.AtomicCache
+}
+
+// NewHandler returns HTTP handler with following endpoints:
+//
+//	GET    /keys     JSON array of all valid keys
+//	GET    /key/{k}  JSON with value, TTL and shard information of key
+//	DELETE /key/{k}  delete key
+//	GET    /stats    JSON statistics snapshot
+//	POST   /gc       run garbage collection
+func NewHandler(c *atomiccache.AtomicCache) nethttp.Handler {
+	return &handler{cache: c}
+}
+
+// ServeHTTP dispatches request to endpoint according to path and method.
+func (h *handler) ServeHTTP(w nethttp.ResponseWriter, r *nethttp.Request) {
+	switch {
+	case r.URL.Path == "/keys":
+		h.allow(w, r, nethttp.MethodGet, h.keys)
+	case r.URL.Path == "/stats":
+		h.allow(w, r, nethttp.MethodGet, h.stats)
+	case r.URL.Path == "/gc":
+		h.allow(w, r, nethttp.MethodPost, h.gc)
+	case strings.HasPrefix(r.URL.Path, keyPrefix) && len(r.URL.Path) > len(keyPrefix):
+		h.allow(w, r, nethttp.MethodGet+","+nethttp.MethodDelete, h.key)
+	default:
+		nethttp.NotFound(w, r)
+	}
+}
+
+// allow calls endpoint if request method is one of comma separated methods.
+// Otherwise 405 status is returned.
+func (h *handler) allow(w nethttp.ResponseWriter, r *nethttp.Request, methods string, endpoint nethttp.HandlerFunc) {
+	for _, method := range strings.Split(methods, ",") {
+		if r.Method == method {
+			endpoint(w, r)
+			return
+		}
+	}
+
+	w.Header().Set("Allow", strings.ReplaceAll(methods, ",", ", "))
+	nethttp.Error(w, nethttp.StatusText(nethttp.StatusMethodNotAllowed), nethttp.StatusMethodNotAllowed)
+}
+
+func (h *handler) keys(w nethttp.ResponseWriter, r *nethttp.Request) {
+	keys := []string{}
+	for _, key := range h.cache.Scan("") {
+		keys = append(keys, string(key))
+	}
+
+	writeJSON(w, nethttp.StatusOK, keys)
+}
+
+func (h *handler) key(w nethttp.ResponseWriter, r *nethttp.Request) {
+	key := []byte(strings.TrimPrefix(r.URL.Path, keyPrefix))
+
+	if r.Method == nethttp.MethodDelete {
+		if err := h.cache.Delete(key); err != nil {
+			writeError(w, err)
+			return
+		}
+		w.WriteHeader(nethttp.StatusNoContent)
+		return
+	}
+
+	data, err := h.cache.Get(key)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	info := KeyInfo{Key: string(key), Value: data}
+	if ttl, err := h.cache.TTL(key); err == nil {
+		if ttl == atomiccache.NoExpiry {
+			info.TTL = "never"
+		} else {
+			info.TTL = ttl.Round(time.Millisecond).String()
+		}
+	}
+	if section, err := h.cache.Type(key); err == nil {
+		info.Section = section
+	}
+	if meta, err := h.cache.GetMeta(key); err == nil {
+		info.ShardIndex = meta.ShardIndex
+		info.RecordIndex = meta.RecordIndex
+	}
+
+	writeJSON(w, nethttp.StatusOK, info)
+}
+
+func (h *handler) stats(w nethttp.ResponseWriter, r *nethttp.Request) {
+	writeJSON(w, nethttp.StatusOK, h.cache.Stats())
+}
+
+func (h *handler) gc(w nethttp.ResponseWriter, r *nethttp.Request) {
+	h.cache.CollectGarbage()
+	w.WriteHeader(nethttp.StatusNoContent)
+}
+
+// writeJSON writes JSON encoded value with specified status.
+func writeJSON(w nethttp.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// writeError writes cache error with corresponding status.
+func writeError(w nethttp.ResponseWriter, err error) {
+	status := nethttp.StatusInternalServerError
+	switch {
+	case errors.Is(err, atomiccache.ErrNotFound), errors.Is(err, atomiccache.ErrExpired):
+		status = nethttp.StatusNotFound
+	case errors.Is(err, atomiccache.ErrKeyTooLong), errors.Is(err, atomiccache.ErrKeyTooShort):
+		status = nethttp.StatusBadRequest
+	}
+
+	nethttp.Error(w, err.Error(), status)
+}
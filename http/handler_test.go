@@ -0,0 +1,66 @@
+package http
+
+import (
+	"encoding/json"
+	nethttp "net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	atomiccache "github.com/PraserX/atomic-cache"
+)
+
+func TestHandler(t *testing.T) {
+	cache := atomiccache.New()
+	cache.Set([]byte("key"), []byte("data"), 0)
+	handler := NewHandler(cache)
+
+	var tests = []struct {
+		method string
+		path   string
+		status int
+	}{
+		{nethttp.MethodGet, "/keys", nethttp.StatusOK},
+		{nethttp.MethodGet, "/key/key", nethttp.StatusOK},
+		{nethttp.MethodGet, "/key/missing", nethttp.StatusNotFound},
+		{nethttp.MethodGet, "/stats", nethttp.StatusOK},
+		{nethttp.MethodGet, "/gc", nethttp.StatusMethodNotAllowed},
+		{nethttp.MethodPost, "/gc", nethttp.StatusNoContent},
+		{nethttp.MethodDelete, "/key/key", nethttp.StatusNoContent},
+		{nethttp.MethodDelete, "/key/key", nethttp.StatusNotFound},
+		{nethttp.MethodGet, "/unknown", nethttp.StatusNotFound},
+	}
+
+	for _, c := range tests {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(c.method, c.path, nil))
+		if w.Code != c.status {
+			t.Errorf("%s %s: %v != %v", c.method, c.path, w.Code, c.status)
+		}
+	}
+}
+
+func TestHandlerKey(t *testing.T) {
+	cache := atomiccache.New()
+	cache.Set([]byte("key"), []byte("data"), 0)
+	handler := NewHandler(cache)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(nethttp.MethodGet, "/key/key", nil))
+
+	var info KeyInfo
+	if err := json.NewDecoder(w.Body).Decode(&info); err != nil {
+		t.Fatalf("Decode error: %s", err.Error())
+	}
+	if !reflect.DeepEqual(info.Value, []byte("data")) || info.Section != "small" {
+		t.Errorf("Unexpected key info: %+v", info)
+	}
+
+	w = httptest.NewRecorder()
+	handler.ServeHTTP(w, httptest.NewRequest(nethttp.MethodGet, "/keys", nil))
+
+	var keys []string
+	if err := json.NewDecoder(w.Body).Decode(&keys); err != nil || !reflect.DeepEqual(keys, []string{"key"}) {
+		t.Errorf("%v != %v", keys, []string{"key"})
+	}
+}
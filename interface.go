@@ -0,0 +1,80 @@
+package atomiccache
+
+import (
+	"time"
+)
+
+// Cache interface describes basic cache memory operations. It is implemented
+// by AtomicCache and NamespacedCache, so code can be written against the
+// interface and a test double can be injected instead of full implementation.
+type Cache interface {
+	Set(key []byte, data []byte, expire time.Duration) error
+	Get(key []byte) ([]byte, error)
+	Delete(key []byte) error
+	Exists(key []byte) bool
+	Expire(key []byte, expire time.Duration) error
+	TTL(key []byte) (time.Duration, error)
+	GetOrSet(key []byte, fn func() ([]byte, error), expire time.Duration) ([]byte, error)
+	Scan(prefix string) [][]byte
+	Flush() error
+	Close() error
+}
+
+// nopCache is a Cache implementation which stores nothing.
+type nopCache struct{}
+
+// NewNopCache returns cache which stores nothing. Every lookup ends up with
+// ErrNotFound. It is useful as a stub in tests.
+func NewNopCache() Cache {
+	return nopCache{}
+}
+
+// Set does nothing.
+func (nopCache) Set(key []byte, data []byte, expire time.Duration) error {
+	return nil
+}
+
+// Get always returns ErrNotFound.
+func (nopCache) Get(key []byte) ([]byte, error) {
+	return nil, ErrNotFound
+}
+
+// Delete always returns ErrNotFound.
+func (nopCache) Delete(key []byte) error {
+	return ErrNotFound
+}
+
+// Exists always returns false.
+func (nopCache) Exists(key []byte) bool {
+	return false
+}
+
+// Expire always returns ErrNotFound.
+func (nopCache) Expire(key []byte, expire time.Duration) error {
+	return ErrNotFound
+}
+
+// TTL always returns ErrNotFound.
+func (nopCache) TTL(key []byte) (time.Duration, error) {
+	return 0, ErrNotFound
+}
+
+// GetOrSet calls function on input and returns its result.
+func (nopCache) GetOrSet(key []byte, fn func() ([]byte, error), expire time.Duration) ([]byte, error) {
+	return fn()
+}
+
+// Scan always returns empty list.
+func (nopCache) Scan(prefix string) [][]byte {
+	return nil
+}
+
+// Flush does nothing.
+func (nopCache) Flush() error {
+	return nil
+}
+
+// Close does nothing.
+func (nopCache) Close() error {
+	return nil
+}
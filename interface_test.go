@@ -0,0 +1,26 @@
+package atomiccache
+
+import (
+	"reflect"
+	"testing"
+)
+
+var (
+	_ Cache = &AtomicCache{}
+	_ Cache = NamespacedCache{}
+)
+
+func TestNopCache(t *testing.T) {
+	cache := NewNopCache()
+	if err := cache.Set([]byte("key"), []byte("data"), 0); err != nil {
+		t.Errorf("Set error: %s", err.Error())
+	}
+	if _, err := cache.Get([]byte("key")); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+
+	value, err := cache.GetOrSet([]byte("key"), func() ([]byte, error) { return []byte("data"), nil }, 0)
+	if err != nil || !reflect.DeepEqual(value, []byte("data")) {
+		t.Errorf("%v != %v", value, []byte("data"))
+	}
+}
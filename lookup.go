@@ -0,0 +1,348 @@
+package atomiccache
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+
+	"github.com/emirpasic/gods/trees/btree"
+)
+
+// LookupBackend represents global index of cache memory. It maps keys to
+// lookup records. Implementations are not required to be thread safe, because
+// all access is guarded by the cache lock.
+type LookupBackend interface {
+	// Get returns lookup record of key. Second value is false if key is not
+	// present.
+	Get(key string) (LookupRecord, bool)
+	// Put stores lookup record of key. Previous record is replaced.
+	Put(key string, record LookupRecord)
+	// Remove removes lookup record of key.
+	Remove(key string)
+	// Keys returns list of all stored keys.
+	Keys() []string
+	// Size returns number of stored keys.
+	Size() int
+	// Clear removes all stored keys.
+	Clear()
+}
+
+// OrderedLookupBackend is lookup backend which keeps keys in lexicographical
+// order. It allows efficient range iteration.
+type OrderedLookupBackend interface {
+	LookupBackend
+	// Ascend calls function for every key greater or equal to from in
+	// ascending order, until the function returns false.
+	Ascend(from string, fn func(key string, record LookupRecord) bool)
+}
+
+// BTreeLookup is lookup backend based on BTree structure. It provides
+// O(log n) lookups and keeps keys in lexicographical order.
+type BTreeLookup struct {
+	tree *btree.Tree
+}
+
+// NewBTreeLookup initialize BTree lookup backend with specified tree degree.
+func NewBTreeLookup(degree int) *BTreeLookup {
+	return &BTreeLookup{tree: btree.NewWithStringComparator(degree)}
+}
+
+// Get returns lookup record of key.
+func (b *BTreeLookup) Get(key string) (LookupRecord, bool) {
+	if val, ok := b.tree.Get(key); ok {
+		return val.(LookupRecord), true
+	}
+
+	return LookupRecord{}, false
+}
+
+// Put stores lookup record of key.
+func (b *BTreeLookup) Put(key string, record LookupRecord) {
+	b.tree.Put(key, record)
+}
+
+// Remove removes lookup record of key.
+func (b *BTreeLookup) Remove(key string) {
+	b.tree.Remove(key)
+}
+
+// Keys returns list of all stored keys in lexicographical order.
+func (b *BTreeLookup) Keys() []string {
+	keys := make([]string, 0, b.tree.Size())
+	for _, key := range b.tree.Keys() {
+		keys = append(keys, key.(string))
+	}
+
+	return keys
+}
+
+// Ascend calls function for every key greater or equal to from in ascending
+// order, until the function returns false. Subtrees with lower keys are not
+// visited.
+func (b *BTreeLookup) Ascend(from string, fn func(key string, record LookupRecord) bool) {
+	if b.tree.Root != nil {
+		ascendNode(b.tree.Root, from, fn)
+	}
+}
+
+// ascendNode provides in-order traversal of BTree node which skips all keys
+// lower than from. It returns false if traversal was stopped.
+func ascendNode(node *btree.Node, from string, fn func(key string, record LookupRecord) bool) bool {
+	entries := node.Entries
+	i := sort.Search(len(entries), func(i int) bool {
+		return entries[i].Key.(string) >= from
+	})
+
+	for ; i < len(entries); i++ {
+		if len(node.Children) != 0 && !ascendNode(node.Children[i], from, fn) {
+			return false
+		}
+		if !fn(entries[i].Key.(string), entries[i].Value.(LookupRecord)) {
+			return false
+		}
+	}
+
+	if len(node.Children) != 0 {
+		return ascendNode(node.Children[len(entries)], from, fn)
+	}
+
+	return true
+}
+
+// Size returns number of stored keys.
+func (b *BTreeLookup) Size() int {
+	return b.tree.Size()
+}
+
+// Clear removes all stored keys.
+func (b *BTreeLookup) Clear() {
+	b.tree.Clear()
+}
+
+// HashmapLookup is lookup backend based on hash map. It provides O(1) average
+// lookups, but keys are not ordered.
+type HashmapLookup struct {
+	records map[string]LookupRecord
+}
+
+// NewHashmapLookup initialize hash map lookup backend.
+func NewHashmapLookup() *HashmapLookup {
+	return &HashmapLookup{records: make(map[string]LookupRecord)}
+}
+
+// Get returns lookup record of key.
+func (h *HashmapLookup) Get(key string) (LookupRecord, bool) {
+	val, ok := h.records[key]
+	return val, ok
+}
+
+// Put stores lookup record of key.
+func (h *HashmapLookup) Put(key string, record LookupRecord) {
+	h.records[key] = record
+}
+
+// Remove removes lookup record of key.
+func (h *HashmapLookup) Remove(key string) {
+	delete(h.records, key)
+}
+
+// Keys returns list of all stored keys in random order.
+func (h *HashmapLookup) Keys() []string {
+	keys := make([]string, 0, len(h.records))
+	for key := range h.records {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// Size returns number of stored keys.
+func (h *HashmapLookup) Size() int {
+	return len(h.records)
+}
+
+// Clear removes all stored keys.
+func (h *HashmapLookup) Clear() {
+	h.records = make(map[string]LookupRecord)
+}
+
+// HashedLookup is lookup backend wrapper which stores keys hashed by 64-bit
+// FNV-1a to underlying backend. Keys with common prefixes are distributed
+// evenly, which keeps BTree nodes balanced. Original key is stored in lookup
+// record (Key field), so keys are verified on Get and hash collisions are
+// stored separately. Keys are not ordered, so range iteration of ordered
+// backend cannot be used.
+type HashedLookup struct {
+	backend    LookupBackend
+	collisions map[string]LookupRecord
+	hash       func(key string) string
+}
+
+// NewHashedLookup initialize hashed lookup wrapper of backend with random hash
+// seed, so distribution of keys cannot be predicted from outside.
+func NewHashedLookup(backend LookupBackend) *HashedLookup {
+	return NewHashedLookupSeed(backend, randomSeed())
+}
+
+// NewHashedLookupSeed initialize hashed lookup wrapper of backend with fixed
+// hash seed. Keys are hashed the same way across restarts, which is useful
+// for reproducible tests.
+func NewHashedLookupSeed(backend LookupBackend, seed uint64) *HashedLookup {
+	return &HashedLookup{backend: backend, collisions: make(map[string]LookupRecord), hash: fnvHash(seed)}
+}
+
+// Get returns lookup record of key.
+func (h *HashedLookup) Get(key string) (LookupRecord, bool) {
+	if val, ok := h.backend.Get(h.hash(key)); ok && val.Key == key {
+		return val, true
+	}
+
+	val, ok := h.collisions[key]
+	return val, ok
+}
+
+// Put stores lookup record of key. If hash of key is used by another key, the
+// record is stored to collisions.
+func (h *HashedLookup) Put(key string, record LookupRecord) {
+	record.Key = key
+
+	if _, ok := h.collisions[key]; ok {
+		h.collisions[key] = record
+		return
+	}
+
+	hash := h.hash(key)
+	if val, ok := h.backend.Get(hash); ok && val.Key != key {
+		h.collisions[key] = record
+		return
+	}
+
+	h.backend.Put(hash, record)
+}
+
+// Remove removes lookup record of key.
+func (h *HashedLookup) Remove(key string) {
+	if _, ok := h.collisions[key]; ok {
+		delete(h.collisions, key)
+		return
+	}
+
+	hash := h.hash(key)
+	if val, ok := h.backend.Get(hash); ok && val.Key == key {
+		h.backend.Remove(hash)
+	}
+}
+
+// Keys returns list of all stored original keys in random order.
+func (h *HashedLookup) Keys() []string {
+	keys := make([]string, 0, h.Size())
+	for _, hash := range h.backend.Keys() {
+		if val, ok := h.backend.Get(hash); ok {
+			keys = append(keys, val.Key)
+		}
+	}
+	for key := range h.collisions {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// Size returns number of stored keys.
+func (h *HashedLookup) Size() int {
+	return h.backend.Size() + len(h.collisions)
+}
+
+// Clear removes all stored keys.
+func (h *HashedLookup) Clear() {
+	h.backend.Clear()
+	h.collisions = make(map[string]LookupRecord)
+}
+
+// randomSeed returns cryptographically secure random hash seed.
+func randomSeed() uint64 {
+	var buf [8]byte
+	rand.Read(buf[:])
+
+	return binary.BigEndian.Uint64(buf[:])
+}
+
+// fnvHash returns function which computes 64-bit FNV-1a hash of key as 8 bytes
+// long string. Seed is hashed before the key, so the offset basis is derived
+// from the seed.
+func fnvHash(seed uint64) func(key string) string {
+	var basis uint64 = 14695981039346656037
+	for i := 0; i < 8; i++ {
+		basis ^= seed >> (8 * i) & 0xff
+		basis *= 1099511628211
+	}
+
+	return func(key string) string {
+		hash := basis
+		for i := 0; i < len(key); i++ {
+			hash ^= uint64(key[i])
+			hash *= 1099511628211
+		}
+
+		var buf [8]byte
+		binary.BigEndian.PutUint64(buf[:], hash)
+
+		return string(buf[:])
+	}
+}
+
+// SaltedLookup is lookup backend wrapper which stores keys hashed by
+// HMAC-SHA256 with secret salt (hex encoded) to underlying backend, so
+// distribution of lookup keys cannot be predicted without the salt and two
+// caches with different salts never share the same lookup key. Original key is
+// never stored in the lookup table, so Keys returns digests (see Hash), which
+// cannot be passed back to Get, Put or Remove.
+type SaltedLookup struct {
+	backend LookupBackend
+	salt    []byte
+}
+
+// NewSaltedLookup initialize salted lookup wrapper of backend.
+func NewSaltedLookup(backend LookupBackend, salt []byte) *SaltedLookup {
+	return &SaltedLookup{backend: backend, salt: append([]byte(nil), salt...)}
+}
+
+// Hash returns hex encoded HMAC-SHA256 of key, which is used as lookup key.
+func (s *SaltedLookup) Hash(key string) string {
+	mac := hmac.New(sha256.New, s.salt)
+	mac.Write([]byte(key))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Get returns lookup record of key.
+func (s *SaltedLookup) Get(key string) (LookupRecord, bool) {
+	return s.backend.Get(s.Hash(key))
+}
+
+// Put stores lookup record of key.
+func (s *SaltedLookup) Put(key string, record LookupRecord) {
+	s.backend.Put(s.Hash(key), record)
+}
+
+// Remove removes lookup record of key.
+func (s *SaltedLookup) Remove(key string) {
+	s.backend.Remove(s.Hash(key))
+}
+
+// Keys returns list of all stored digests of keys in random order.
+func (s *SaltedLookup) Keys() []string {
+	return s.backend.Keys()
+}
+
+// Size returns number of stored keys.
+func (s *SaltedLookup) Size() int {
+	return s.backend.Size()
+}
+
+// Clear removes all stored keys.
+func (s *SaltedLookup) Clear() {
+	s.backend.Clear()
+}
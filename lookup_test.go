@@ -0,0 +1,206 @@
+package atomiccache
+
+import (
+	"bytes"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestLookupBackends(t *testing.T) {
+	for _, backend := range []LookupBackend{NewBTreeLookup(3), NewHashmapLookup(), NewHashedLookup(NewBTreeLookup(3))} {
+		backend.Put("b", LookupRecord{RecordIndex: 2})
+		backend.Put("a", LookupRecord{RecordIndex: 1})
+		backend.Put("c", LookupRecord{RecordIndex: 3})
+		backend.Remove("c")
+
+		if val, ok := backend.Get("a"); !ok || val.RecordIndex != 1 {
+			t.Errorf("%T: %v != %v", backend, val.RecordIndex, 1)
+		}
+		if _, ok := backend.Get("c"); ok {
+			t.Errorf("%T: removed key is still present", backend)
+		}
+
+		keys := backend.Keys()
+		sort.Strings(keys)
+		if !reflect.DeepEqual(keys, []string{"a", "b"}) || backend.Size() != 2 {
+			t.Errorf("%T: %v != %v", backend, keys, []string{"a", "b"})
+		}
+
+		backend.Clear()
+		if backend.Size() != 0 {
+			t.Errorf("%T: %v != %v", backend, backend.Size(), 0)
+		}
+	}
+}
+
+func TestCacheBTreeLookupBackend(t *testing.T) {
+	cache := New(OptionLookupBackend(NewBTreeLookup(3)))
+	cache.Set([]byte("key"), []byte("data"), 0)
+
+	if value, err := cache.Get([]byte("key")); err != nil || !reflect.DeepEqual(value, []byte("data")) {
+		t.Errorf("%v != %v", value, []byte("data"))
+	}
+}
+
+func TestBTreeLookupAscend(t *testing.T) {
+	var keys []string
+
+	backend := NewBTreeLookup(3)
+	for _, key := range []string{"d", "a", "c", "b", "e"} {
+		backend.Put(key, LookupRecord{})
+	}
+
+	backend.Ascend("b", func(key string, record LookupRecord) bool {
+		keys = append(keys, key)
+		return key != "d"
+	})
+
+	if !reflect.DeepEqual(keys, []string{"b", "c", "d"}) {
+		t.Errorf("%v != %v", keys, []string{"b", "c", "d"})
+	}
+}
+
+func TestBTreeDegreeOption(t *testing.T) {
+	cache := New(OptionBTreeDegree(32))
+	if _, ok := cache.lookup.(*BTreeLookup); !ok {
+		t.Errorf("BTree lookup backend is not used")
+	}
+
+	cache.Set([]byte("key"), []byte("data"), 0)
+	if data, err := cache.Get([]byte("key")); err != nil || string(data) != "data" {
+		t.Errorf("%q != %q", data, "data")
+	}
+
+	if _, err := NewWithError(OptionBTreeDegree(2)); err != ErrBTreeDegree {
+		t.Errorf("Expecting error 'ErrBTreeDegree'")
+	}
+}
+
+func TestHashedLookupCollisions(t *testing.T) {
+	backend := NewHashedLookup(NewHashmapLookup())
+	backend.hash = func(string) string { return "hash" }
+
+	backend.Put("a", LookupRecord{RecordIndex: 1})
+	backend.Put("b", LookupRecord{RecordIndex: 2})
+	backend.Put("b", LookupRecord{RecordIndex: 3})
+
+	for key, want := range map[string]uint32{"a": 1, "b": 3} {
+		if val, ok := backend.Get(key); !ok || val.RecordIndex != want || val.Key != key {
+			t.Errorf("%v: %v != %v", key, val.RecordIndex, want)
+		}
+	}
+	if backend.Size() != 2 {
+		t.Errorf("%v != %v", backend.Size(), 2)
+	}
+
+	backend.Remove("a")
+	if _, ok := backend.Get("a"); ok {
+		t.Errorf("Removed key is still present")
+	}
+	if val, ok := backend.Get("b"); !ok || val.RecordIndex != 3 {
+		t.Errorf("%v != %v", val.RecordIndex, 3)
+	}
+}
+
+func TestHashedLookupSeed(t *testing.T) {
+	a := NewHashedLookupSeed(NewHashmapLookup(), 42)
+	b := NewHashedLookupSeed(NewHashmapLookup(), 42)
+	c := NewHashedLookupSeed(NewHashmapLookup(), 43)
+
+	if a.hash("key") != b.hash("key") {
+		t.Errorf("Hashes with the same seed are different")
+	}
+	if a.hash("key") == c.hash("key") {
+		t.Errorf("Hashes with different seeds are equal")
+	}
+
+	cache := New(OptionHashKeys(true), OptionHashSeed(42))
+	cache.Set([]byte("key"), []byte("data"), 0)
+	if _, ok := cache.lookup.(*HashedLookup).backend.Get(a.hash("key")); !ok {
+		t.Errorf("Key is not stored under seeded hash")
+	}
+}
+
+func TestCacheHashKeys(t *testing.T) {
+	cache := New(OptionHashKeys(true), OptionBTreeDegree(3))
+	for _, key := range []string{"user:00000001", "user:00000002", "post:1"} {
+		cache.Set([]byte(key), []byte(key), 0)
+	}
+
+	if value, err := cache.Get([]byte("user:00000002")); err != nil || !reflect.DeepEqual(value, []byte("user:00000002")) {
+		t.Errorf("%q != %q", value, "user:00000002")
+	}
+
+	expected := [][]byte{[]byte("user:00000001"), []byte("user:00000002")}
+	if keys := cache.Scan("user:"); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("%q != %q", keys, expected)
+	}
+}
+
+func TestCacheKeySalt(t *testing.T) {
+	cache1 := New(OptionKeySalt([]byte("salt1")))
+	cache2 := New(OptionKeySalt([]byte("salt2")))
+
+	if cache1.KeyHash([]byte("key")) == cache2.KeyHash([]byte("key")) {
+		t.Errorf("Different salts produce the same key hash")
+	}
+
+	cache1.Set([]byte("key"), []byte("data"), time.Millisecond)
+	if value, err := cache1.Get([]byte("key")); err != nil || !reflect.DeepEqual(value, []byte("data")) {
+		t.Errorf("%v != %v", value, []byte("data"))
+	}
+
+	if keys := cache1.Scan(""); len(keys) != 0 {
+		t.Errorf("%q != %q", keys, [][]byte{})
+	}
+
+	time.Sleep(5 * time.Millisecond)
+	cache1.CollectGarbage()
+	if items := cache1.Stats().Items; items != 0 {
+		t.Errorf("%v != %v", items, 0)
+	}
+}
+
+func TestCacheKeySaltDigest(t *testing.T) {
+	cache := New(OptionKeySalt([]byte("salt")))
+	cache.Set([]byte("key"), []byte("data"), 0)
+
+	if _, err := cache.Get([]byte(cache.KeyHash([]byte("key")))); err == nil {
+		t.Errorf("Record is readable by its digest")
+	}
+
+	salted := cache.lookup.(*SaltedLookup)
+	if keys := salted.Keys(); !reflect.DeepEqual(keys, []string{cache.KeyHash([]byte("key"))}) {
+		t.Errorf("%q != %q", keys, []string{cache.KeyHash([]byte("key"))})
+	}
+	if val, err := cache.GetMeta([]byte("key")); err != nil || val.Key != "" {
+		t.Errorf("%q, %v != \"\", <nil>", val.Key, err)
+	}
+}
+
+func TestCacheKeySaltUnsupported(t *testing.T) {
+	cache := New(OptionKeySalt([]byte("salt")))
+	cache.Set([]byte("key"), []byte("data"), 0)
+
+	if _, err := cache.Clone(); err != ErrKeysSalted {
+		t.Errorf("Expecting error 'ErrKeysSalted', got %v", err)
+	}
+	if err := cache.Dump(&bytes.Buffer{}); err != ErrKeysSalted {
+		t.Errorf("Expecting error 'ErrKeysSalted', got %v", err)
+	}
+	if _, _, err := New().MergeFrom(cache, OverwriteExisting); err != ErrKeysSalted {
+		t.Errorf("Expecting error 'ErrKeysSalted', got %v", err)
+	}
+	if _, err := cache.DeletePrefix([]byte("k")); err != ErrKeysSalted {
+		t.Errorf("Expecting error 'ErrKeysSalted', got %v", err)
+	}
+	if _, err := cache.RandomKey(); err != ErrKeysSalted {
+		t.Errorf("Expecting error 'ErrKeysSalted', got %v", err)
+	}
+
+	if data, err := cache.Get([]byte("key")); err != nil || string(data) != "data" {
+		t.Errorf("%s, %v != data, <nil>", data, err)
+	}
+}
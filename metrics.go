@@ -0,0 +1,48 @@
+package atomiccache
+
+import (
+	"time"
+)
+
+// MetricsRecorder receives cache events, so metrics can be exported to any
+// metrics backend (Prometheus, Datadog, StatsD, in-memory counters, ...)
+// without dependency of the core cache on specific library. Methods are
+// called synchronously (some of them while cache lock is held), so they should
+// be fast and must not call cache methods.
+type MetricsRecorder interface {
+	// RecordHit is called for every successful Get.
+	RecordHit()
+	// RecordMiss is called for every Get which does not find the record.
+	RecordMiss()
+	// RecordEviction is called for every record removed by garbage collector
+	// or eviction policy.
+	RecordEviction()
+	// RecordSetLatency is called with duration of every Set.
+	RecordSetLatency(d time.Duration)
+	// RecordGetLatency is called with duration of every Get lookup.
+	RecordGetLatency(d time.Duration)
+	// RecordGCDuration is called with duration of every garbage collection.
+	RecordGCDuration(d time.Duration)
+}
+
+// NoopMetrics is MetricsRecorder which records nothing. It is used if no
+// metrics recorder is set.
+type NoopMetrics struct{}
+
+// RecordHit does nothing.
+func (NoopMetrics) RecordHit() {}
+
+// RecordMiss does nothing.
+func (NoopMetrics) RecordMiss() {}
+
+// RecordEviction does nothing.
+func (NoopMetrics) RecordEviction() {}
+
+// RecordSetLatency does nothing.
+func (NoopMetrics) RecordSetLatency(d time.Duration) {}
+
+// RecordGetLatency does nothing.
+func (NoopMetrics) RecordGetLatency(d time.Duration) {}
+
+// RecordGCDuration does nothing.
+func (NoopMetrics) RecordGCDuration(d time.Duration) {}
@@ -0,0 +1,48 @@
+package atomiccache
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// testMetrics counts recorded events.
+type testMetrics struct {
+	hits, misses, evictions, sets, gets, gcs atomic.Int64
+}
+
+func (m *testMetrics) RecordHit()                       { m.hits.Add(1) }
+func (m *testMetrics) RecordMiss()                      { m.misses.Add(1) }
+func (m *testMetrics) RecordEviction()                  { m.evictions.Add(1) }
+func (m *testMetrics) RecordSetLatency(d time.Duration) { m.sets.Add(1) }
+func (m *testMetrics) RecordGetLatency(d time.Duration) { m.gets.Add(1) }
+func (m *testMetrics) RecordGCDuration(d time.Duration) { m.gcs.Add(1) }
+
+func TestCacheMetrics(t *testing.T) {
+	metrics := &testMetrics{}
+	cache := New(OptionMetrics(metrics))
+
+	cache.Set([]byte("key"), []byte("data"), 0)
+	cache.Set([]byte("expired"), []byte("data"), time.Millisecond)
+	cache.Get([]byte("key"))
+	cache.Get([]byte("missing"))
+	time.Sleep(5 * time.Millisecond)
+	cache.CollectGarbage()
+
+	for _, c := range []struct {
+		name  string
+		value int64
+		want  int64
+	}{
+		{"hits", metrics.hits.Load(), 1},
+		{"misses", metrics.misses.Load(), 1},
+		{"evictions", metrics.evictions.Load(), 1},
+		{"sets", metrics.sets.Load(), 2},
+		{"gets", metrics.gets.Load(), 2},
+		{"gcs", metrics.gcs.Load(), 1},
+	} {
+		if c.value != c.want {
+			t.Errorf("[%s] %v != %v", c.name, c.value, c.want)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package atomiccache
+
+import (
+	"time"
+)
+
+// NamespacedCache is a view of AtomicCache which scopes all keys to one
+// namespace. Every key is automatically prefixed with namespace name and colon
+// separator, so multiple subsystems can share one cache memory without key
+// collisions.
+type NamespacedCache struct {
+	cache  *AtomicCache
+	prefix string
+}
+
+// WithNamespace returns namespaced view of cache memory. All keys passed to the
+// view are prefixed with "ns:".
+func (a *AtomicCache) WithNamespace(ns string) NamespacedCache {
+	return NamespacedCache{cache: a, prefix: ns + ":"}
+}
+
+// Set store data to cache memory under namespaced key.
+func (n NamespacedCache) Set(key []byte, data []byte, expire time.Duration) error {
+	return n.cache.Set(n.key(key), data, expire)
+}
+
+// Get returns data stored under namespaced key.
+func (n NamespacedCache) Get(key []byte) ([]byte, error) {
+	return n.cache.Get(n.key(key))
+}
+
+// Delete removes record stored under namespaced key.
+func (n NamespacedCache) Delete(key []byte) error {
+	return n.cache.Delete(n.key(key))
+}
+
+// Exists returns true if namespaced key is present and valid.
+func (n NamespacedCache) Exists(key []byte) bool {
+	return n.cache.Exists(n.key(key))
+}
+
+// Expire sets new expiration time of record stored under namespaced key.
+func (n NamespacedCache) Expire(key []byte, expire time.Duration) error {
+	return n.cache.Expire(n.key(key), expire)
+}
+
+// TTL returns remaining time to live of record stored under namespaced key.
+func (n NamespacedCache) TTL(key []byte) (time.Duration, error) {
+	return n.cache.TTL(n.key(key))
+}
+
+// GetOrSet returns data stored under namespaced key or stores result of the
+// function on input.
+func (n NamespacedCache) GetOrSet(key []byte, fn func() ([]byte, error), expire time.Duration) ([]byte, error) {
+	return n.cache.GetOrSet(n.key(key), fn, expire)
+}
+
+// Flush removes all records from namespace (see DeletePrefix). Records of
+// other namespaces are kept untouched. Error of the cache (e.g. ErrReadOnly)
+// is returned.
+func (n NamespacedCache) Flush() error {
+	_, err := n.cache.DeletePrefix([]byte(n.prefix))
+	return err
+}
+
+// Close does nothing, because namespaced view does not own the cache memory.
+func (n NamespacedCache) Close() error {
+	return nil
+}
+
+// Scan returns list of valid keys from namespace which starts with prefix. The
+// namespace prefix is stripped from returned keys.
+func (n NamespacedCache) Scan(prefix string) [][]byte {
+	keys := n.cache.Scan(n.prefix + prefix)
+	for i := range keys {
+		keys[i] = keys[i][len(n.prefix):]
+	}
+
+	return keys
+}
+
+// key returns key with namespace prefix.
+func (n NamespacedCache) key(key []byte) []byte {
+	result := make([]byte, 0, len(n.prefix)+len(key))
+	result = append(result, n.prefix...)
+	return append(result, key...)
+}
@@ -0,0 +1,53 @@
+package atomiccache
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNamespacedCacheIsolation(t *testing.T) {
+	cache := New()
+	users := cache.WithNamespace("users")
+	posts := cache.WithNamespace("posts")
+
+	users.Set([]byte("1"), []byte("user"), 0)
+	posts.Set([]byte("1"), []byte("post"), 0)
+
+	if value, err := users.Get([]byte("1")); err != nil || !reflect.DeepEqual(value, []byte("user")) {
+		t.Errorf("%v != %v", value, []byte("user"))
+	}
+	if value, err := cache.Get([]byte("posts:1")); err != nil || !reflect.DeepEqual(value, []byte("post")) {
+		t.Errorf("%v != %v", value, []byte("post"))
+	}
+
+	if keys := users.Scan(""); !reflect.DeepEqual(keys, [][]byte{[]byte("1")}) {
+		t.Errorf("%v != %v", keys, [][]byte{[]byte("1")})
+	}
+
+	users.Delete([]byte("1"))
+	if users.Exists([]byte("1")) || !posts.Exists([]byte("1")) {
+		t.Errorf("Delete removed record from different namespace")
+	}
+}
+
+func TestNamespacedCacheFlush(t *testing.T) {
+	cache := New()
+	users := cache.WithNamespace("users")
+	posts := cache.WithNamespace("posts")
+
+	users.Set([]byte("1"), []byte("user"), 0)
+	posts.Set([]byte("1"), []byte("post"), 0)
+
+	cache.SetReadOnly(true)
+	if err := users.Flush(); err != ErrReadOnly {
+		t.Errorf("Expecting error 'ErrReadOnly'")
+	}
+	cache.SetReadOnly(false)
+
+	if err := users.Flush(); err != nil {
+		t.Errorf("Flush error: %s", err.Error())
+	}
+	if users.Exists([]byte("1")) || !posts.Exists([]byte("1")) {
+		t.Errorf("Flush removed wrong records")
+	}
+}
@@ -0,0 +1,79 @@
+// Package otel provides OpenTelemetry tracing for atomic cache operations. It
+// is a separate package, so the core cache does not depend on OpenTelemetry.
+package otel
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"time"
+
+	atomiccache "github.com/PraserX/atomic-cache"
+	otelapi "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// TracerName is the instrumentation name used for created spans.
+const TracerName = "github.com/PraserX/atomic-cache"
+
+// TracedCache wraps AtomicCache and provides context aware variants of cache
+// operations. Every call starts OpenTelemetry span.
+type TracedCache struct {
+	*atomiccache.AtomicCache
+	tracer trace.Tracer
+}
+
+// NewTracedCache returns traced cache which uses global tracer provider.
+func NewTracedCache(c *atomiccache.AtomicCache) *TracedCache {
+	return NewTracedCacheWithTracer(c, otelapi.Tracer(TracerName))
+}
+
+// NewTracedCacheWithTracer returns traced cache which uses tracer on input.
+func NewTracedCacheWithTracer(c *atomiccache.AtomicCache, tracer trace.Tracer) *TracedCache {
+	return &TracedCache{AtomicCache: c, tracer: tracer}
+}
+
+// SetWithContext store data to cache memory within "atomiccache.Set" span.
+func (t *TracedCache) SetWithContext(ctx context.Context, key []byte, data []byte, expire time.Duration) error {
+	_, span := t.tracer.Start(ctx, "atomiccache.Set", trace.WithAttributes(attribute.String("cache.key_hash", hashKey(key))))
+	defer span.End()
+
+	if err := t.Set(key, data, expire); err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+
+	if section, err := t.Type(key); err == nil {
+		span.SetAttributes(attribute.String("cache.section", section))
+	}
+
+	return nil
+}
+
+// GetWithContext returns data from cache memory within "atomiccache.Get" span.
+func (t *TracedCache) GetWithContext(ctx context.Context, key []byte) ([]byte, error) {
+	_, span := t.tracer.Start(ctx, "atomiccache.Get", trace.WithAttributes(attribute.String("cache.key_hash", hashKey(key))))
+	defer span.End()
+
+	data, err := t.Get(key)
+	span.SetAttributes(attribute.Bool("cache.hit", err == nil))
+	if err != nil {
+		return nil, err
+	}
+
+	if section, err := t.Type(key); err == nil {
+		span.SetAttributes(attribute.String("cache.section", section))
+	}
+
+	return data, nil
+}
+
+// hashKey returns hex encoded SHA-256 hash of key, so raw keys are not
+// exported to tracing backend.
+func hashKey(key []byte) string {
+	sum := sha256.Sum256(key)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,126 @@
+package atomiccache
+
+import (
+	"time"
+)
+
+// Pipeline operation types.
+const (
+	pipelineSet = iota
+	pipelineGet
+	pipelineDelete
+	pipelineExpire
+)
+
+// pipelineOperation represents one queued pipeline operation.
+type pipelineOperation struct {
+	op     int
+	key    []byte
+	data   []byte
+	expire time.Duration
+}
+
+// PipelineResult contains result of one pipeline operation. Data are filled
+// only for Get operation.
+type PipelineResult struct {
+	Data []byte
+	Err  error
+}
+
+// Pipeline queues multiple cache operations and executes them at once with
+// single lock acquisition. Pipeline is not thread safe.
+type Pipeline struct {
+	cache      *AtomicCache
+	operations []pipelineOperation
+}
+
+// Pipeline returns new empty pipeline for cache memory.
+func (a *AtomicCache) Pipeline() *Pipeline {
+	return &Pipeline{cache: a}
+}
+
+// Set queues Set operation. Write-through store is not used for pipeline.
+func (p *Pipeline) Set(key []byte, data []byte, expire time.Duration) *Pipeline {
+	p.operations = append(p.operations, pipelineOperation{op: pipelineSet, key: key, data: data, expire: expire})
+	return p
+}
+
+// Get queues Get operation. Loader is not used for pipeline.
+func (p *Pipeline) Get(key []byte) *Pipeline {
+	p.operations = append(p.operations, pipelineOperation{op: pipelineGet, key: key})
+	return p
+}
+
+// Delete queues Delete operation.
+func (p *Pipeline) Delete(key []byte) *Pipeline {
+	p.operations = append(p.operations, pipelineOperation{op: pipelineDelete, key: key})
+	return p
+}
+
+// Expire queues Expire operation.
+func (p *Pipeline) Expire(key []byte, expire time.Duration) *Pipeline {
+	p.operations = append(p.operations, pipelineOperation{op: pipelineExpire, key: key, expire: expire})
+	return p
+}
+
+// Exec executes all queued operations in order under one write lock and
+// returns their results. Pipeline is empty after execution.
+func (p *Pipeline) Exec() []PipelineResult {
+	a := p.cache
+	collectGarbage := false
+	results := make([]PipelineResult, len(p.operations))
+
+	a.Lock()
+	for i, operation := range p.operations {
+		if err := a.checkKey(operation.key); err != nil {
+			results[i].Err = err
+			continue
+		}
+		if operation.op != pipelineGet {
+			if err := a.checkWritable(); err != nil {
+				results[i].Err = err
+				continue
+			}
+		}
+
+		switch operation.op {
+		case pipelineSet:
+			if len(operation.data) > int(a.maxItemSize) {
+				results[i].Err = ErrDataLimit
+				continue
+			}
+			if a.valueValidator != nil {
+				if err := a.valueValidator(operation.key, operation.data); err != nil {
+					results[i].Err = err
+					continue
+				}
+			}
+			gc, err := a.setLocked(operation.key, operation.data, operation.expire, a.getExprTime(operation.expire))
+			collectGarbage = collectGarbage || gc
+			results[i].Err = err
+		case pipelineGet:
+			if data, _, err := a.getLocked(operation.key); err == nil {
+				a.stats.hits.Add(1)
+				a.metrics.RecordHit()
+				results[i].Data = data
+			} else {
+				a.stats.misses.Add(1)
+				a.metrics.RecordMiss()
+				results[i].Err = err
+			}
+		case pipelineDelete:
+			results[i].Err = a.removeLocked(operation.key)
+		case pipelineExpire:
+			results[i].Err = a.expireLocked(operation.key, operation.expire)
+		}
+	}
+	a.Unlock()
+
+	p.operations = nil
+
+	if collectGarbage {
+		a.startGarbageCollection()
+	}
+
+	return results
+}
@@ -0,0 +1,27 @@
+package atomiccache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestPipelineExec(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("old"), []byte("data"), 0)
+
+	results := cache.Pipeline().
+		Set([]byte("key"), []byte("value"), 0).
+		Get([]byte("key")).
+		Delete([]byte("old")).
+		Get([]byte("old")).
+		Expire([]byte("key"), time.Minute).
+		Exec()
+
+	want := []PipelineResult{
+		{}, {Data: []byte("value")}, {}, {Err: ErrNotFound}, {},
+	}
+	if !reflect.DeepEqual(results, want) {
+		t.Errorf("%v != %v", results, want)
+	}
+}
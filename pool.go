@@ -0,0 +1,75 @@
+package atomiccache
+
+import (
+	"sync"
+)
+
+// SharedPool is memory pool which can be shared by multiple caches. The pool
+// pre-allocates one large byte array and vends slabs of it as shard memory.
+// Memory of released shard is returned to the pool and reused by next shard of
+// the same size, so shards do not allocate their own memory and the Go garbage
+// collector has less work. If pre-allocated memory is exhausted, slabs are
+// allocated on demand and they are reused after release too. Released slabs
+// are zeroed and caches which use the pool return copies of data, so records
+// of one cache are never visible to another one.
+type SharedPool struct {
+	sync.Mutex
+	memory []byte
+	offset int
+	free   map[int][][]byte
+}
+
+// NewSharedPool returns pool with pre-allocated memory of specified size in
+// bytes.
+func NewSharedPool(size int) *SharedPool {
+	return &SharedPool{
+		memory: make([]byte, size),
+		free:   make(map[int][][]byte),
+	}
+}
+
+// get returns slab of specified size. Released slab is used first, then
+// pre-allocated memory, then new memory is allocated.
+func (p *SharedPool) get(size int) []byte {
+	p.Lock()
+	defer p.Unlock()
+
+	if slabs := p.free[size]; len(slabs) > 0 {
+		slab := slabs[len(slabs)-1]
+		p.free[size] = slabs[:len(slabs)-1]
+		return slab
+	}
+
+	if p.offset+size <= len(p.memory) {
+		slab := p.memory[p.offset : p.offset+size : p.offset+size]
+		p.offset += size
+		return slab
+	}
+
+	return make([]byte, size)
+}
+
+// put returns slab to the pool. Slab is zeroed, so data of one cache cannot
+// be read by another cache which gets the slab later.
+func (p *SharedPool) put(slab []byte) {
+	if slab == nil {
+		return
+	}
+
+	for i := range slab {
+		slab[i] = 0
+	}
+
+	p.Lock()
+	p.free[len(slab)] = append(p.free[len(slab)], slab)
+	p.Unlock()
+}
+
+// Available returns number of pre-allocated bytes which were not vended yet.
+// Released slabs are not included.
+func (p *SharedPool) Available() int {
+	p.Lock()
+	defer p.Unlock()
+
+	return len(p.memory) - p.offset
+}
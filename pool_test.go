@@ -0,0 +1,72 @@
+package atomiccache
+
+import (
+	"testing"
+)
+
+func TestSharedPool(t *testing.T) {
+	pool := NewSharedPool(64)
+
+	slab := pool.get(48)
+	if len(slab) != 48 || pool.Available() != 16 {
+		t.Errorf("%v != %v", pool.Available(), 16)
+	}
+
+	// Pre-allocated memory is exhausted, so new slab is allocated
+	if other := pool.get(48); len(other) != 48 || pool.Available() != 16 {
+		t.Errorf("%v != %v", pool.Available(), 16)
+	}
+
+	pool.put(slab)
+	if reused := pool.get(48); &reused[0] != &slab[0] {
+		t.Errorf("Released slab was not reused")
+	}
+}
+
+func TestCacheSharedPool(t *testing.T) {
+	pool := NewSharedPool(1 << 20)
+	opts := []Option{OptionRecordSizeSmall(8), OptionRecordSizeMedium(16), OptionRecordSizeLarge(32), OptionMaxRecords(1), OptionPool(pool)}
+
+	cache1 := New(opts...)
+	cache2 := New(opts...)
+	if used := 1<<20 - pool.Available(); used != 2*(8+16+32) {
+		t.Errorf("%v != %v", used, 2*(8+16+32))
+	}
+
+	cache1.Set([]byte("key1"), []byte("data1"), 0)
+	cache1.Set([]byte("key2"), []byte("data2"), 0)
+	cache2.Set([]byte("key1"), []byte("data3"), 0)
+
+	// Released shard memory is returned to the pool
+	cache1.Delete([]byte("key2"))
+	if len(pool.free[8]) != 1 {
+		t.Errorf("%v != %v", len(pool.free[8]), 1)
+	}
+
+	if data, err := cache1.Get([]byte("key1")); err != nil || string(data) != "data1" {
+		t.Errorf("%q != %q", data, "data1")
+	}
+	if data, err := cache2.Get([]byte("key1")); err != nil || string(data) != "data3" {
+		t.Errorf("%q != %q", data, "data3")
+	}
+}
+
+func TestCacheSharedPoolIsolation(t *testing.T) {
+	pool := NewSharedPool(0)
+	cache := New(OptionRecordSizeSmall(8), OptionRecordSizeMedium(16), OptionRecordSizeLarge(32), OptionMaxRecords(1), OptionPool(pool))
+	cache.Set([]byte("key"), []byte("secret"), 0)
+	data, _ := cache.Get([]byte("key"))
+
+	// Released shard memory is zeroed and returned to the pool
+	cache.Close()
+	if string(data) != "secret" {
+		t.Errorf("%q != %q", data, "secret")
+	}
+
+	slab := pool.get(8)
+	slab[0] = 1
+	pool.put(slab)
+	if reused := pool.get(8); reused[0] != 0 {
+		t.Errorf("Released slab was not zeroed")
+	}
+}
@@ -0,0 +1,76 @@
+package atomiccache
+
+import (
+	"context"
+)
+
+// runPrefetch reloads near-expired records every prefetch interval until the
+// cache is closed. Interval is measured by cache clock.
+func (a *AtomicCache) runPrefetch() {
+	for {
+		select {
+		case <-a.prefetchStop:
+			return
+		case <-a.clock.After(a.prefetchInterval):
+			a.prefetch()
+		}
+	}
+}
+
+// prefetch reloads records whose remaining TTL is lower than prefetch
+// threshold fraction of their original TTL (default TTL if records were stored
+// with zero duration). Records are loaded by loader (one
+// by one, deduplicated with concurrent misses by loader group) and stored
+// without write-through store. It returns number of reloaded records.
+func (a *AtomicCache) prefetch() int {
+	var keys []string
+
+	now := a.clock.Now()
+	a.RLock()
+	for _, key := range a.lookupKeysLocked() {
+		val, ok := a.lookup.Get(key)
+		if !ok || val.Expiration.IsZero() || !a.isValidRecord(val, now) {
+			continue
+		}
+
+		// Records stored with zero duration expire by default TTL (same way
+		// as in getExprTime).
+		ttl := val.OriginalTTL
+		if ttl == 0 {
+			ttl = a.defaultTTL
+		}
+		if ttl <= 0 {
+			continue
+		}
+		if float64(val.Expiration.Sub(now)) < a.prefetchThreshold*float64(ttl) {
+			keys = append(keys, key)
+		}
+	}
+	a.RUnlock()
+
+	var prefetched int
+	for _, key := range keys {
+		_, err := a.loaderGroup.Do(context.Background(), key, func(ctx context.Context) ([]byte, error) {
+			data, expire, err := a.loader(ctx, []byte(key))
+			if err != nil {
+				return nil, err
+			}
+
+			return data, a.SetNoStore([]byte(key), data, expire)
+		})
+
+		if err != nil {
+			if a.logger != nil {
+				a.logger.Warn("atomiccache: prefetch failed", "key", key, "error", err)
+			}
+			continue
+		}
+
+		prefetched++
+		if a.logger != nil {
+			a.logger.Debug("atomiccache: record prefetched", "key", key)
+		}
+	}
+
+	return prefetched
+}
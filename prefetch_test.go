@@ -0,0 +1,70 @@
+package atomiccache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestCachePrefetch(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	loaded := make(chan string, 10)
+	cache := New(OptionClock(clock), OptionPrefetchThreshold(0.5), OptionPrefetchInterval(time.Minute),
+		OptionLoader(func(ctx context.Context, key []byte) ([]byte, time.Duration, error) {
+			loaded <- string(key)
+			return []byte("fresh"), 10 * time.Minute, nil
+		}))
+	defer cache.Close()
+
+	cache.Set([]byte("key"), []byte("stale"), 10*time.Minute)
+	cache.Set([]byte("forever"), []byte("stale"), NoExpiry)
+
+	if n := cache.prefetch(); n != 0 {
+		t.Errorf("%v != %v", n, 0)
+	}
+
+	clock.Advance(6 * time.Minute)
+	for i := 0; i < 100 && len(loaded) == 0; i++ {
+		time.Sleep(time.Millisecond)
+		clock.Advance(time.Minute)
+	}
+
+	select {
+	case key := <-loaded:
+		if key != "key" {
+			t.Errorf("%v != %v", key, "key")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("Record was not prefetched")
+	}
+
+	for i := 0; i < 100; i++ {
+		if data, _ := cache.Get([]byte("key")); string(data) == "fresh" {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Errorf("Prefetched data are not stored")
+}
+
+func TestCachePrefetchDefaultTTL(t *testing.T) {
+	clock := NewFakeClock(time.Now())
+	cache := New(OptionClock(clock), OptionDefaultTTL(10*time.Minute), OptionPrefetchThreshold(0.5), OptionPrefetchInterval(time.Hour),
+		OptionLoader(func(ctx context.Context, key []byte) ([]byte, time.Duration, error) {
+			return []byte("fresh"), 0, nil
+		}))
+	defer cache.Close()
+
+	cache.Set([]byte("key"), []byte("stale"), 0)
+	if n := cache.prefetch(); n != 0 {
+		t.Errorf("%v != %v", n, 0)
+	}
+
+	clock.Advance(6 * time.Minute)
+	if n := cache.prefetch(); n != 1 {
+		t.Errorf("%v != %v", n, 1)
+	}
+	if data, _ := cache.Get([]byte("key")); string(data) != "fresh" {
+		t.Errorf("%s != %s", data, "fresh")
+	}
+}
@@ -0,0 +1,67 @@
+// Package prom provides Prometheus collector for atomic cache statistics. It
+// is a separate package, so the core cache does not depend on Prometheus.
+package prom
+
+import (
+	"time"
+
+	atomiccache "github.com/PraserX/atomic-cache"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// collector exports cache statistics as Prometheus metrics. It uses Stats
+// snapshot, so no cache lock is held during the scrape.
+type collector struct {
+	cache *atomiccache.AtomicCache
+
+	hits        *prometheus.Desc
+	misses      *prometheus.Desc
+	evictions   *prometheus.Desc
+	items       *prometheus.Desc
+	memoryBytes *prometheus.Desc
+	gcDuration  *prometheus.Desc
+	bufferLen   *prometheus.Desc
+}
+
+// NewPrometheusCollector returns Prometheus collector for cache on input.
+func NewPrometheusCollector(c *atomiccache.AtomicCache) prometheus.Collector {
+	return &collector{
+		cache:       c,
+		hits:        prometheus.NewDesc("cache_hits_total", "Number of cache hits.", nil, nil),
+		misses:      prometheus.NewDesc("cache_misses_total", "Number of cache misses.", nil, nil),
+		evictions:   prometheus.NewDesc("cache_evictions_total", "Number of records evicted by garbage collector.", nil, nil),
+		items:       prometheus.NewDesc("cache_items_current", "Number of records in cache memory.", nil, nil),
+		memoryBytes: prometheus.NewDesc("cache_memory_bytes_used", "Number of bytes allocated by active shards.", nil, nil),
+		gcDuration:  prometheus.NewDesc("cache_gc_duration_seconds", "Duration of garbage collection.", nil, nil),
+		bufferLen:   prometheus.NewDesc("cache_buffer_len", "Number of unattended set requests in buffer.", nil, nil),
+	}
+}
+
+// Describe sends descriptors of all exported metrics.
+func (c *collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.items
+	ch <- c.memoryBytes
+	ch <- c.gcDuration
+	ch <- c.bufferLen
+}
+
+// Collect sends current values of all exported metrics.
+func (c *collector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.cache.Stats()
+
+	buckets := make(map[float64]uint64, len(stats.GcDurationBuckets))
+	for bound, count := range stats.GcDurationBuckets {
+		buckets[bound.Seconds()] = count
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.items, prometheus.GaugeValue, float64(stats.Items))
+	ch <- prometheus.MustNewConstMetric(c.memoryBytes, prometheus.GaugeValue, float64(stats.MemoryBytesUsed))
+	ch <- prometheus.MustNewConstHistogram(c.gcDuration, stats.GcRuns, float64(stats.GcDurationSum)/float64(time.Second), buckets)
+	ch <- prometheus.MustNewConstMetric(c.bufferLen, prometheus.GaugeValue, float64(stats.BufferLen))
+}
@@ -0,0 +1,14 @@
+package prom_test
+
+import (
+	atomiccache "github.com/PraserX/atomic-cache"
+	"github.com/PraserX/atomic-cache/prom"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func ExampleNewPrometheusCollector() {
+	cache := atomiccache.New()
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(prom.NewPrometheusCollector(cache))
+}
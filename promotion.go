@@ -0,0 +1,55 @@
+package atomiccache
+
+// allocLargerLocked stores data to the first section larger than specified
+// section which has available space. Overflow section is skipped, because its
+// records are too large to be used as a fallback. It returns section ID, shard
+// index and record index. Fourth value is false if there is no space left, in
+// which case specified section ID is returned.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) allocLargerLocked(shardSectionID uint8, data []byte) (uint8, uint32, uint32, bool) {
+	for id := int(shardSectionID) + 1; id <= len(a.sections); id++ {
+		if uint8(id) == a.overflowSection {
+			continue
+		}
+		if si, ri, ok := a.allocLocked(uint8(id), data); ok {
+			return uint8(id), si, ri, true
+		}
+	}
+
+	return shardSectionID, 0, 0, false
+}
+
+// promoteLocked moves record to the smallest section where its data fit and
+// which has available space. If there is no such section smaller than the
+// current one, record is not moved. Old slot is freed and updated lookup
+// record is returned (it is not stored to lookup table).
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) promoteLocked(val LookupRecord) LookupRecord {
+	shardSection := a.getShardsSectionByID(val.ShardSection)
+	if shardSection == nil || shardSection.shards[val.ShardIndex] == nil {
+		return val
+	}
+
+	data, err := shardSection.shards[val.ShardIndex].GetVerified(val.RecordIndex)
+	if err != nil {
+		return val
+	}
+
+	_, targetID := a.getShardsSectionBySize(len(data))
+	for id := targetID; id < val.ShardSection; id++ {
+		si, ri, ok := a.allocLocked(id, data)
+		if !ok {
+			continue
+		}
+
+		shardSection.shards[val.ShardIndex].Free(val.RecordIndex)
+		if len(shardSection.shardsActive) > 1 {
+			a.releaseShard(val.ShardSection, val.ShardIndex)
+		}
+
+		val.ShardSection, val.ShardIndex, val.RecordIndex = id, si, ri
+		break
+	}
+
+	return val
+}
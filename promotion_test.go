@@ -0,0 +1,43 @@
+package atomiccache
+
+import (
+	"testing"
+)
+
+func TestCacheTierPromotion(t *testing.T) {
+	cache := New(OptionMaxRecords(2), OptionMaxShardsSmall(1), OptionTierPromotion(true), OptionTierPromotionThreshold(3))
+	cache.Set([]byte("key1"), []byte("data"), 0)
+	cache.Set([]byte("key2"), []byte("data"), 0)
+	if err := cache.Set([]byte("key3"), []byte("data"), 0); err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	if val, _ := cache.lookup.Get("key3"); val.ShardSection != MDSH {
+		t.Errorf("%v != %v", val.ShardSection, MDSH)
+	}
+
+	cache.Delete([]byte("key1"))
+	for i := 0; i < 4; i++ {
+		if data, err := cache.Get([]byte("key3")); err != nil || string(data) != "data" {
+			t.Errorf("%s, %v != data, <nil>", data, err)
+		}
+	}
+
+	if val, _ := cache.lookup.Get("key3"); val.ShardSection != SMSH {
+		t.Errorf("%v != %v", val.ShardSection, SMSH)
+	}
+	if data, err := cache.Get([]byte("key3")); err != nil || string(data) != "data" {
+		t.Errorf("%s, %v != data, <nil>", data, err)
+	}
+}
+
+func TestCacheTierPromotionDisabled(t *testing.T) {
+	cache := New(OptionMaxRecords(2), OptionMaxShardsSmall(1))
+	cache.Set([]byte("key1"), []byte("data"), 0)
+	cache.Set([]byte("key2"), []byte("data"), 0)
+	cache.Set([]byte("key3"), []byte("data"), 0)
+
+	if _, ok := cache.lookup.Get("key3"); ok {
+		t.Errorf("Record stored to larger section")
+	}
+}
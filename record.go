@@ -1,15 +1,33 @@
 package atomiccache
 
 import (
+	"encoding/binary"
+	"hash/crc32"
 	"sync"
+	"sync/atomic"
+	"time"
 )
 
-// Record structure represents one record stored in cache memory.
+// checksumSize is size of CRC32 checksum stored after record data.
+const checksumSize = 4
+
+// Record structure represents one record stored in cache memory. Record memory
+// is allocated once with maximum size and it is reused by following records,
+// so the byte array itself is private. Stored data can be read by Data (copy)
+// and their length by Len (no copy). Records of shard should be accessed only
+// through Shard methods (Set, Get and Free), which keep slot accounting of the
+// shard consistent.
 type Record struct {
 	sync.RWMutex
 	size  uint32
 	alloc uint32
 	data  []byte
+	// Key of stored record and its access statistics, they are maintained by
+	// cache memory for eviction policies and tier promotion. Key is empty if
+	// slot is free.
+	key         string
+	lastAccess  atomic.Int64
+	accessCount atomic.Uint32
 }
 
 // NewRecord initialize one new record and return pointer to them. During
@@ -39,6 +57,43 @@ func (r *Record) Set(data []byte) {
 	r.Unlock() // Unlock for writing and reading
 }
 
+// SetWithChecksum store data to record memory same way as Set, but CRC32
+// checksum of stored data is appended. Record size has to include checksumSize
+// bytes.
+func (r *Record) SetWithChecksum(data []byte) {
+	dataLength := uint32(len(data))
+
+	r.Lock() // Lock for writing and reading
+	if dataLength > r.size-checksumSize {
+		dataLength = r.size - checksumSize
+	}
+	copy(r.data, data[:dataLength])
+	binary.BigEndian.PutUint32(r.data[dataLength:], crc32.ChecksumIEEE(r.data[:dataLength]))
+	r.alloc = dataLength + checksumSize
+	r.Unlock() // Unlock for writing and reading
+}
+
+// GetWithChecksum returns bytes stored by SetWithChecksum (without checksum).
+// If stored checksum does not match, then ErrChecksumMismatch is returned.
+func (r *Record) GetWithChecksum() ([]byte, error) {
+	r.RLock() // Lock for reading
+	defer r.RUnlock()
+
+	if r.alloc == 0 {
+		return r.data[:0], nil
+	}
+	if r.alloc < checksumSize {
+		return nil, ErrChecksumMismatch
+	}
+
+	data := r.data[:r.alloc-checksumSize]
+	if binary.BigEndian.Uint32(r.data[len(data):r.alloc]) != crc32.ChecksumIEEE(data) {
+		return nil, ErrChecksumMismatch
+	}
+
+	return data, nil
+}
+
 // Get returns bytes based on size of virtual allocation. It means that it
 // returns only specific count of bytes, based on alloc property. If array on
 // output is empty, then record is not exists.
@@ -55,9 +110,38 @@ func (r *Record) Get() []byte {
 func (r *Record) Free() {
 	r.Lock() // Lock for writing and reading
 	r.alloc = 0
+	r.key = ""
 	r.Unlock() // Unlock for writing and reading
 }
 
+// setAccess stores key of record and its access statistics.
+func (r *Record) setAccess(key string, lastAccess time.Time, accessCount uint32) {
+	r.key = key
+	if lastAccess.IsZero() {
+		r.lastAccess.Store(0)
+	} else {
+		r.lastAccess.Store(lastAccess.UnixNano())
+	}
+	r.accessCount.Store(accessCount)
+}
+
+// access returns last access time and access count of record.
+func (r *Record) access() (time.Time, uint32) {
+	var lastAccess time.Time
+	if nanos := r.lastAccess.Load(); nanos != 0 {
+		lastAccess = time.Unix(0, nanos)
+	}
+
+	return lastAccess, r.accessCount.Load()
+}
+
+// touch updates access statistics of record and returns new access count. It
+// is safe to call it concurrently under read lock of cache memory.
+func (r *Record) touch(now time.Time) uint32 {
+	r.lastAccess.Store(now.UnixNano())
+	return r.accessCount.Add(1)
+}
+
 // GetAllocated returns size of allocated bytes.
 func (r *Record) GetAllocated() uint32 {
 	r.RLock() // Lock for reading
@@ -66,6 +150,24 @@ func (r *Record) GetAllocated() uint32 {
 	return data
 }
 
+// Data returns copy of stored data (including checksum if record was stored
+// by SetWithChecksum). The copy can be retained and modified safely.
+func (r *Record) Data() []byte {
+	r.RLock() // Lock for reading
+	data := append([]byte{}, r.data[:r.alloc]...)
+	r.RUnlock() // Unlock for reading
+	return data
+}
+
+// Len returns number of stored bytes (including checksum if record was stored
+// by SetWithChecksum).
+func (r *Record) Len() int {
+	r.RLock() // Lock for reading
+	length := int(r.alloc)
+	r.RUnlock() // Unlock for reading
+	return length
+}
+
 // GetDataLength returns real size of allocated bytes in memory.
 func (r *Record) GetDataLength() uint32 {
 	r.RLock() // Lock for reading
@@ -35,6 +35,51 @@ func TestRecordFree(t *testing.T) {
 	}
 }
 
+func TestRecordChecksum(t *testing.T) {
+	want := []byte{0, 1, 2}
+
+	record := NewRecord(10)
+	record.SetWithChecksum(want)
+	if data, err := record.GetWithChecksum(); err != nil || !reflect.DeepEqual(data, want) {
+		t.Errorf("%v != %v", data, want)
+	}
+
+	record.data[1] ^= 0xFF
+	if _, err := record.GetWithChecksum(); err != ErrChecksumMismatch {
+		t.Errorf("Expecting error 'ErrChecksumMismatch'")
+	}
+}
+
+func TestRecordData(t *testing.T) {
+	want := []byte{0, 1, 2}
+
+	record := NewRecord(10)
+	record.Set(want)
+
+	data := record.Data()
+	if !reflect.DeepEqual(data, want) || record.Len() != len(want) {
+		t.Errorf("%v != %v", data, want)
+	}
+
+	data[0] = 0xFF
+	if !reflect.DeepEqual(record.Get(), want) {
+		t.Errorf("Record data are modified through copy")
+	}
+}
+
+func TestRecordEmpty(t *testing.T) {
+	record := NewRecord(10)
+	record.Set([]byte{0, 1, 2})
+	record.Set([]byte{})
+
+	if data := record.Get(); data == nil || len(data) != 0 {
+		t.Errorf("%#v != %#v", data, []byte{})
+	}
+	if data := record.Data(); data == nil || len(data) != 0 {
+		t.Errorf("%#v != %#v", data, []byte{})
+	}
+}
+
 func benchmarkRecordNew(size uint32, b *testing.B) {
 	b.ReportAllocs()
 
@@ -0,0 +1,140 @@
+package atomiccache
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrReservation is returned if reservation is not valid, it is exhausted or
+// data do not belong to reserved shard section.
+var ErrReservation = errors.New("Reservation is not valid")
+
+// ReservationToken identifies slots reserved by Reserve.
+type ReservationToken struct {
+	section uint8
+	id      uint64
+}
+
+// Section returns ID of shard section where slots are reserved.
+func (t ReservationToken) Section() uint8 {
+	return t.section
+}
+
+// Reserve reserves count slots of shard section. Reserved slots are not used
+// by Set (or other methods), they are used only by SetReserved with returned
+// token, so the holder never gets ErrFullMemory for reserved records. Shards
+// are allocated to cover all reserved slots. If there is not enough free slots
+// in the section, ErrFullMemory is returned. Reservations are kept after
+// Flush, unused slots can be returned by CancelReservation.
+func (a *AtomicCache) Reserve(section uint8, count uint32) (ReservationToken, error) {
+	if err := a.lockWritable(); err != nil {
+		return ReservationToken{}, err
+	}
+	defer a.Unlock()
+
+	shardSection := a.getShardsSectionByID(section)
+	if shardSection == nil {
+		return ReservationToken{}, ErrReservation
+	}
+
+	if a.freeSlotsLocked(shardSection)-shardSection.reserved < count {
+		return ReservationToken{}, ErrFullMemory
+	}
+	shardSection.reserved += count
+
+	var allocated uint32
+	for _, shardIndex := range shardSection.shardsActive {
+		allocated += shardSection.shards[shardIndex].GetSlotsAvail()
+	}
+	for allocated < shardSection.reserved {
+		si, ok := a.getEmptyShard(section)
+		if !ok {
+			break
+		}
+		if shardSection.shards[si] == nil {
+			shardSection.shards[si] = a.allocShard(shardSection)
+		}
+		allocated += shardSection.shards[si].GetSlotsAvail()
+	}
+
+	if a.reservations == nil {
+		a.reservations = make(map[uint64]uint32)
+	}
+	a.reservationID++
+	a.reservations[a.reservationID] = count
+
+	return ReservationToken{section: section, id: a.reservationID}, nil
+}
+
+// SetReserved store data to reserved slot same way as Set, but write-through
+// store is bypassed. Data have to belong to reserved shard section. One slot
+// of reservation is used (even if record replaces previous record of the key).
+// If reservation is exhausted, ErrReservation is returned.
+func (a *AtomicCache) SetReserved(token ReservationToken, key []byte, data []byte, expire time.Duration) error {
+	if err := a.checkKey(key); err != nil {
+		return err
+	}
+
+	if len(data) > int(a.maxItemSize) {
+		return ErrDataLimit
+	}
+
+	if a.valueValidator != nil {
+		if err := a.valueValidator(key, data); err != nil {
+			return err
+		}
+	}
+
+	if err := a.lockWritable(); err != nil {
+		return err
+	}
+	shardSection, _ := a.getShardsSectionBySize(len(data))
+	remaining := a.reservations[token.id]
+	if remaining == 0 || shardSection.id != token.section {
+		a.Unlock()
+		return ErrReservation
+	}
+
+	if remaining == 1 {
+		delete(a.reservations, token.id)
+	} else {
+		a.reservations[token.id] = remaining - 1
+	}
+	shardSection.reserved--
+
+	collectGarbage, err := a.setLocked(key, data, expire, a.getExprTime(expire))
+	a.Unlock()
+
+	if collectGarbage {
+		a.startGarbageCollection()
+	}
+
+	return err
+}
+
+// CancelReservation returns unused slots of reservation, so they can be used
+// by Set again.
+func (a *AtomicCache) CancelReservation(token ReservationToken) {
+	a.Lock()
+	defer a.Unlock()
+
+	remaining, ok := a.reservations[token.id]
+	if !ok {
+		return
+	}
+
+	delete(a.reservations, token.id)
+	a.getShardsSectionByID(token.section).reserved -= remaining
+}
+
+// freeSlotsLocked returns number of free slots of shard section (including
+// slots of shards which are not allocated yet).
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) freeSlotsLocked(shardSection *ShardsLookup) uint32 {
+	free := (shardSection.maxShards - uint32(len(shardSection.shardsActive))) * shardSection.slotCount
+	for _, shardIndex := range shardSection.shardsActive {
+		free += shardSection.shards[shardIndex].GetSlotsAvail()
+	}
+
+	return free
+}
@@ -0,0 +1,52 @@
+package atomiccache
+
+import (
+	"testing"
+)
+
+func TestCacheReserve(t *testing.T) {
+	cache := New(OptionMaxRecords(2), OptionMaxShardsSmall(2), OptionGcStarter(1000))
+
+	if _, err := cache.Reserve(SMSH, 5); err != ErrFullMemory {
+		t.Errorf("Expecting error 'ErrFullMemory'")
+	}
+
+	token, err := cache.Reserve(SMSH, 3)
+	if err != nil {
+		t.Errorf("Reserve error: %s", err.Error())
+	}
+
+	if err := cache.SetNoStore([]byte("key1"), []byte("data"), 0); err != nil {
+		t.Errorf("Set error: %s", err.Error())
+	}
+	cache.SetNoStore([]byte("key2"), []byte("data"), 0)
+	if cache.Exists([]byte("key2")) {
+		t.Errorf("Reserved slot is used by Set")
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := cache.SetReserved(token, []byte{'r', byte(i)}, []byte("data"), 0); err != nil {
+			t.Errorf("SetReserved error: %s", err.Error())
+		}
+	}
+	if err := cache.SetReserved(token, []byte("r3"), []byte("data"), 0); err != ErrReservation {
+		t.Errorf("Expecting error 'ErrReservation'")
+	}
+	if items := cache.Stats().Items; items != 4 {
+		t.Errorf("%v != %v", items, 4)
+	}
+}
+
+func TestCacheCancelReservation(t *testing.T) {
+	cache := New(OptionMaxRecords(1), OptionMaxShardsSmall(1), OptionGcStarter(1000))
+
+	token, _ := cache.Reserve(SMSH, 1)
+	cache.CancelReservation(token)
+
+	if err := cache.SetNoStore([]byte("key"), []byte("data"), 0); err != nil || !cache.Exists([]byte("key")) {
+		t.Errorf("Cancelled reservation is still held")
+	}
+	if err := cache.SetReserved(token, []byte("key"), []byte("data"), 0); err != ErrReservation {
+		t.Errorf("Expecting error 'ErrReservation'")
+	}
+}
@@ -4,11 +4,15 @@ import (
 	"sync"
 )
 
-// Shard structure contains multiple slots for records.
+// Shard structure contains multiple slots for records. Set, Get and Free are
+// the safe API of shard, they keep list of available slots consistent with
+// records stored in slots.
 type Shard struct {
 	sync.RWMutex
 	slotAvail []uint32
 	slots     []*Record
+	checksums bool
+	memory    []byte
 }
 
 // NewShard initialize list of records with specified size. List is stored
@@ -16,7 +20,31 @@ type Shard struct {
 // propagated to record instance). Argument slotCount represents number of
 // records in shard and slotSize represents size of one record.
 func NewShard(slotCount, slotSize uint32) *Shard {
-	shard := &Shard{}
+	return newShard(slotCount, slotSize, false, nil)
+}
+
+// newShard initialize shard same way as NewShard. If checksums are enabled,
+// every record is 4 bytes larger, so CRC32 checksum can be stored after data.
+// If pool is set, memory of all records is one slab taken from the pool.
+func newShard(slotCount, slotSize uint32, checksums bool, pool *SharedPool) *Shard {
+	return newShardWithOptions(&ShardOptions{SlotCount: slotCount, SlotSize: slotSize, Checksums: checksums, Pool: pool})
+}
+
+// newShardWithOptions initialize shard according to options. Memory of all
+// records is one slab if pool is set (slab is taken from the pool) or if
+// preallocation is enabled.
+func newShardWithOptions(options *ShardOptions) *Shard {
+	slotCount, slotSize := options.SlotCount, options.SlotSize
+
+	shard := &Shard{checksums: options.Checksums}
+	if options.Checksums {
+		slotSize += checksumSize
+	}
+	if options.Pool != nil {
+		shard.memory = options.Pool.get(int(slotCount) * int(slotSize))
+	} else if options.Preallocate {
+		shard.memory = make([]byte, int(slotCount)*int(slotSize))
+	}
 
 	// Initialize available slots stack
 	for i := uint32(0); i < slotCount; i++ {
@@ -25,7 +53,11 @@ func NewShard(slotCount, slotSize uint32) *Shard {
 
 	// Initialize record list
 	for i := uint32(0); i < slotCount; i++ {
-		shard.slots = append(shard.slots, NewRecord(slotSize))
+		if shard.memory != nil {
+			shard.slots = append(shard.slots, &Record{size: slotSize, data: shard.memory[i*slotSize : (i+1)*slotSize : (i+1)*slotSize]})
+		} else {
+			shard.slots = append(shard.slots, NewRecord(slotSize))
+		}
 	}
 
 	return shard
@@ -41,7 +73,11 @@ func (s *Shard) Set(data []byte) uint32 {
 	s.Unlock() // Unlock for writing and reading
 
 	s.RLock()
-	s.slots[index].Set(data)
+	if s.checksums {
+		s.slots[index].SetWithChecksum(data)
+	} else {
+		s.slots[index].Set(data)
+	}
 	s.RUnlock()
 
 	return index
@@ -50,12 +86,24 @@ func (s *Shard) Set(data []byte) uint32 {
 // Get returns bytes from shard memory based on index. If array on output is
 // empty, then record is not exists.
 func (s *Shard) Get(index uint32) []byte {
-	s.RLock()
-	value := s.slots[index].Get()
-	s.RUnlock()
+	value, _ := s.GetVerified(index)
 	return value
 }
 
+// GetVerified returns bytes from shard memory same way as Get. If checksums are
+// enabled and stored checksum does not match, then ErrChecksumMismatch is
+// returned.
+func (s *Shard) GetVerified(index uint32) ([]byte, error) {
+	s.RLock()
+	defer s.RUnlock()
+
+	if s.checksums {
+		return s.slots[index].GetWithChecksum()
+	}
+
+	return s.slots[index].Get(), nil
+}
+
 // Free empty memory specified by index on input and increase slot counter.
 func (s *Shard) Free(index uint32) {
 	s.Lock()
@@ -0,0 +1,69 @@
+package atomiccache
+
+// ShardOptions are used for Shard construct function.
+type ShardOptions struct {
+	// Number of records in shard.
+	SlotCount uint32
+	// Size of one record.
+	SlotSize uint32
+	// Store CRC32 checksum with every record and verify it on Get.
+	Checksums bool
+	// Allocate memory of all records as one contiguous slab.
+	Preallocate bool
+	// Memory pool used for slab allocation (disabled if nil).
+	Pool *SharedPool
+}
+
+// ShardOption specification for Shard construct function.
+type ShardOption func(*ShardOptions)
+
+// NewShardWithOptions initialize shard same way as NewShard, but its behavior
+// is specified by options. By default, shard has 2048 records of 512 bytes
+// (same as small shard of cache memory) without checksums.
+func NewShardWithOptions(opts ...ShardOption) *Shard {
+	options := &ShardOptions{
+		SlotCount: 2048,
+		SlotSize:  512,
+	}
+
+	for _, opt := range opts {
+		opt(options)
+	}
+
+	return newShardWithOptions(options)
+}
+
+// ShardOptionSlotCount option specification.
+func ShardOptionSlotCount(option uint32) ShardOption {
+	return func(opts *ShardOptions) {
+		opts.SlotCount = option
+	}
+}
+
+// ShardOptionSlotSize option specification.
+func ShardOptionSlotSize(option uint32) ShardOption {
+	return func(opts *ShardOptions) {
+		opts.SlotSize = option
+	}
+}
+
+// ShardOptionChecksums option specification.
+func ShardOptionChecksums(option bool) ShardOption {
+	return func(opts *ShardOptions) {
+		opts.Checksums = option
+	}
+}
+
+// ShardOptionPreallocate option specification.
+func ShardOptionPreallocate(option bool) ShardOption {
+	return func(opts *ShardOptions) {
+		opts.Preallocate = option
+	}
+}
+
+// ShardOptionPool option specification.
+func ShardOptionPool(option *SharedPool) ShardOption {
+	return func(opts *ShardOptions) {
+		opts.Pool = option
+	}
+}
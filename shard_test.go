@@ -134,3 +134,20 @@ func BenchmarkShardGetMedium(b *testing.B) {
 func BenchmarkShardGetLarge(b *testing.B) {
 	benchmarkShardGet(16384, 4096, 2048, b)
 }
+
+func TestShardWithOptions(t *testing.T) {
+	shard := NewShardWithOptions(ShardOptionSlotCount(4), ShardOptionSlotSize(8), ShardOptionChecksums(true), ShardOptionPreallocate(true))
+
+	if avail := shard.GetSlotsAvail(); avail != 4 {
+		t.Errorf("%v != %v", avail, 4)
+	}
+	if size := len(shard.memory); size != 4*(8+checksumSize) {
+		t.Errorf("%v != %v", size, 4*(8+checksumSize))
+	}
+
+	index := shard.Set([]byte("data"))
+	shard.slots[index].data[0] ^= 0xff
+	if _, err := shard.GetVerified(index); err != ErrChecksumMismatch {
+		t.Errorf("Expecting error 'ErrChecksumMismatch'")
+	}
+}
@@ -0,0 +1,95 @@
+package atomiccache
+
+import (
+	"sort"
+	"time"
+)
+
+// snapshotRecord is record of snapshot.
+type snapshotRecord struct {
+	data []byte
+	ttl  time.Duration
+}
+
+// Snapshot is immutable point-in-time copy of all valid records. Its data
+// never change and never expire, so it can be read without any lock.
+type Snapshot struct {
+	records map[string]snapshotRecord
+	keys    []string
+}
+
+// Snapshot returns consistent copy of all valid records. All data are copied
+// under read lock, so concurrent writes are blocked during the copy. If keys
+// are salted, only counters are copied, because original keys of lookup table
+// are not known.
+func (a *AtomicCache) Snapshot() *Snapshot {
+	var keys []string
+
+	snapshot := &Snapshot{records: make(map[string]snapshotRecord)}
+
+	a.RLock()
+	if !a.keysSalted() {
+		keys = a.lookup.Keys()
+	}
+	for key := range a.counterIndex {
+		keys = append(keys, key)
+	}
+	now := a.clock.Now()
+	for _, key := range keys {
+		data, val, err := a.getLocked([]byte(key))
+		if err != nil {
+			continue
+		}
+
+		ttl := NoExpiry
+		if !val.Expiration.IsZero() {
+			ttl = val.Expiration.Sub(now)
+		}
+
+		snapshot.records[key] = snapshotRecord{data: append([]byte(nil), data...), ttl: ttl}
+		snapshot.keys = append(snapshot.keys, key)
+	}
+	a.RUnlock()
+
+	sort.Strings(snapshot.keys)
+
+	return snapshot
+}
+
+// Get returns copy of record data. If record is not part of snapshot, then
+// ErrNotFound is returned.
+func (s *Snapshot) Get(key []byte) ([]byte, error) {
+	record, ok := s.records[string(key)]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	return append([]byte{}, record.data...), nil
+}
+
+// Keys returns sorted list of all keys of snapshot.
+func (s *Snapshot) Keys() [][]byte {
+	keys := make([][]byte, len(s.keys))
+	for i, key := range s.keys {
+		keys[i] = []byte(key)
+	}
+
+	return keys
+}
+
+// Range calls function for every record of snapshot in key order until the
+// function returns false. TTL is remaining time to live at the time of
+// snapshot (NoExpiry if record never expires). Data must not be modified.
+func (s *Snapshot) Range(fn func(key, data []byte, ttl time.Duration) bool) {
+	for _, key := range s.keys {
+		record := s.records[key]
+		if !fn([]byte(key), record.data, record.ttl) {
+			return
+		}
+	}
+}
+
+// Len returns number of records of snapshot.
+func (s *Snapshot) Len() int {
+	return len(s.keys)
+}
@@ -0,0 +1,41 @@
+package atomiccache
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestCacheSnapshot(t *testing.T) {
+	cache := New()
+	cache.Set([]byte("key1"), []byte("data1"), NoExpiry)
+	cache.Set([]byte("key2"), []byte("data2"), time.Hour)
+
+	snapshot := cache.Snapshot()
+
+	// Snapshot does not change with cache
+	cache.Set([]byte("key1"), []byte("changed"), 0)
+	cache.Delete([]byte("key2"))
+	cache.Set([]byte("key3"), []byte("data3"), 0)
+
+	if data, err := snapshot.Get([]byte("key1")); err != nil || !reflect.DeepEqual(data, []byte("data1")) {
+		t.Errorf("%q != %q", data, "data1")
+	}
+	if _, err := snapshot.Get([]byte("key3")); err != ErrNotFound {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+
+	expected := [][]byte{[]byte("key1"), []byte("key2")}
+	if keys := snapshot.Keys(); !reflect.DeepEqual(keys, expected) {
+		t.Errorf("%q != %q", keys, expected)
+	}
+
+	var ttls []time.Duration
+	snapshot.Range(func(key, data []byte, ttl time.Duration) bool {
+		ttls = append(ttls, ttl)
+		return true
+	})
+	if len(ttls) != 2 || ttls[0] != NoExpiry || ttls[1] <= 0 || ttls[1] > time.Hour {
+		t.Errorf("Unexpected TTLs: %v", ttls)
+	}
+}
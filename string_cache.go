@@ -0,0 +1,37 @@
+package atomiccache
+
+import (
+	"time"
+)
+
+// StringCache is a view of AtomicCache which accepts string keys. Keys are
+// converted to byte slices (copied), so callbacks of the cache (e.g. loader,
+// value validator or watchers) never get memory of the string.
+type StringCache struct {
+	cache *AtomicCache
+}
+
+// WithStringKeys returns view of cache memory which accepts string keys.
+func (a *AtomicCache) WithStringKeys() StringCache {
+	return StringCache{cache: a}
+}
+
+// SetStr store data to cache memory under string key.
+func (s StringCache) SetStr(key string, data []byte, expire time.Duration) error {
+	return s.cache.Set([]byte(key), data, expire)
+}
+
+// GetStr returns data stored under string key.
+func (s StringCache) GetStr(key string) ([]byte, error) {
+	return s.cache.Get([]byte(key))
+}
+
+// DeleteStr removes record stored under string key.
+func (s StringCache) DeleteStr(key string) error {
+	return s.cache.Delete([]byte(key))
+}
+
+// ExistsStr returns true if string key is present and valid.
+func (s StringCache) ExistsStr(key string) bool {
+	return s.cache.Exists([]byte(key))
+}
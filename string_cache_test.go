@@ -0,0 +1,57 @@
+package atomiccache
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestStringCache(t *testing.T) {
+	cache := New()
+	strings := cache.WithStringKeys()
+
+	if err := strings.SetStr("key", []byte("data"), 0); err != nil {
+		t.Errorf("Set error: %s", err.Error())
+	}
+
+	if value, err := strings.GetStr("key"); err != nil || !reflect.DeepEqual(value, []byte("data")) {
+		t.Errorf("%v != %v", value, []byte("data"))
+	}
+	if value, err := cache.Get([]byte("key")); err != nil || !reflect.DeepEqual(value, []byte("data")) {
+		t.Errorf("%v != %v", value, []byte("data"))
+	}
+
+	strings.DeleteStr("key")
+	if strings.ExistsStr("key") {
+		t.Errorf("Record is present after delete")
+	}
+
+	if err := strings.SetStr("", []byte("data"), 0); err != ErrKeyTooShort {
+		t.Errorf("Expecting error 'ErrKeyTooShort'")
+	}
+}
+
+func BenchmarkStringCacheGetStr(b *testing.B) {
+	cache := New()
+	strings := cache.WithStringKeys()
+	strings.SetStr("key", []byte("data"), 0)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		strings.GetStr("key")
+	}
+}
+
+func BenchmarkStringCacheGet(b *testing.B) {
+	cache := New()
+	cache.Set([]byte("key"), []byte("data"), 0)
+	key := "key"
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for n := 0; n < b.N; n++ {
+		cache.Get([]byte(key))
+	}
+}
@@ -0,0 +1,62 @@
+// Package testutil provides helpers for tests of code which uses atomic cache.
+// Cache created by NewTestCache uses fake clock, so expiration can be tested
+// deterministically without sleeping.
+package testutil
+
+import (
+	"testing"
+	"time"
+
+	atomiccache "github.com/PraserX/atomic-cache"
+)
+
+// FakeClock is clock which is moved forward only by Advance (see
+// atomiccache.FakeClock).
+type FakeClock = atomiccache.FakeClock
+
+// NewTestCache returns cache memory created with options on input and fake
+// clock set to current time. The cache is closed when the test finishes. If
+// options are not valid, the test fails.
+func NewTestCache(t testing.TB, opts ...atomiccache.Option) (*atomiccache.AtomicCache, *FakeClock) {
+	t.Helper()
+
+	clock := atomiccache.NewFakeClock(time.Now())
+	cache, err := atomiccache.NewWithError(append(opts[:len(opts):len(opts)], atomiccache.OptionClock(clock))...)
+	if err != nil {
+		t.Fatalf("atomiccache: %v", err)
+	}
+	t.Cleanup(func() { cache.Close() })
+
+	return cache, clock
+}
+
+// MustSet stores data to cache memory. If Set fails, the test fails.
+func MustSet(t testing.TB, cache *atomiccache.AtomicCache, key []byte, data []byte, expire time.Duration) {
+	t.Helper()
+
+	if err := cache.Set(key, data, expire); err != nil {
+		t.Fatalf("atomiccache: set %q: %v", key, err)
+	}
+}
+
+// MustGet returns data of record. If Get fails, the test fails.
+func MustGet(t testing.TB, cache *atomiccache.AtomicCache, key []byte) []byte {
+	t.Helper()
+
+	data, err := cache.Get(key)
+	if err != nil {
+		t.Fatalf("atomiccache: get %q: %v", key, err)
+	}
+
+	return data
+}
+
+// MustDelete removes record from cache memory. If Delete fails, the test
+// fails.
+func MustDelete(t testing.TB, cache *atomiccache.AtomicCache, key []byte) {
+	t.Helper()
+
+	if err := cache.Delete(key); err != nil {
+		t.Fatalf("atomiccache: delete %q: %v", key, err)
+	}
+}
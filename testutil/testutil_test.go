@@ -0,0 +1,40 @@
+package testutil
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	atomiccache "github.com/PraserX/atomic-cache"
+)
+
+func TestNewTestCache(t *testing.T) {
+	cache, clock := NewTestCache(t)
+
+	MustSet(t, cache, []byte("key"), []byte("data"), time.Minute)
+	if data := MustGet(t, cache, []byte("key")); string(data) != "data" {
+		t.Errorf("%s != data", data)
+	}
+
+	clock.Advance(time.Minute)
+	if _, err := cache.Get([]byte("key")); !errors.Is(err, atomiccache.ErrNotFound) {
+		t.Errorf("Expecting error 'ErrNotFound'")
+	}
+
+	MustSet(t, cache, []byte("key"), []byte("data"), time.Minute)
+	MustDelete(t, cache, []byte("key"))
+	if cache.Exists([]byte("key")) {
+		t.Errorf("Deleted record exists")
+	}
+}
+
+func TestNewTestCacheOptions(t *testing.T) {
+	opts := make([]atomiccache.Option, 1, 2)
+	opts[0] = atomiccache.OptionMaxRecords(16)
+	spare := opts[:2]
+
+	NewTestCache(t, opts...)
+	if spare[1] != nil {
+		t.Errorf("Options of the caller were modified")
+	}
+}
@@ -0,0 +1,73 @@
+package atomiccache
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// traceKeyLength is maximum number of key bytes written to debug trace.
+const traceKeyLength = 32
+
+// debugTrace writes one line per cache operation to writer. Lines have stable
+// format of space separated name=value pairs, which is prefixed by
+// "atomiccache", e.g.:
+//
+//	atomiccache op=set key="user:1" result=ok section=1 shard=0 record=3
+//	atomiccache op=gc evicted=2 duration=1.2ms
+type debugTrace struct {
+	sync.Mutex
+	w io.Writer
+}
+
+// traceResults maps cache errors to result names used in debug trace.
+var traceResults = map[error]string{
+	ErrNotFound:         "not_found",
+	ErrExpired:          "expired",
+	ErrDataLimit:        "data_limit",
+	ErrFullMemory:       "full_memory",
+	ErrKeyTooLong:       "key_too_long",
+	ErrKeyTooShort:      "key_too_short",
+	ErrChecksumMismatch: "checksum_mismatch",
+}
+
+// traceResult returns result name of error.
+func traceResult(err error) string {
+	if err == nil {
+		return "ok"
+	}
+	if result, ok := traceResults[err]; ok {
+		return result
+	}
+
+	return "error"
+}
+
+// traceOp writes line of key operation to debug trace (if it is enabled).
+// Section, shard and record are taken from lookup record.
+func (a *AtomicCache) traceOp(op string, key []byte, err error, val LookupRecord) {
+	if a.trace == nil {
+		return
+	}
+
+	if len(key) > traceKeyLength {
+		key = key[:traceKeyLength]
+	}
+
+	a.trace.Lock()
+	fmt.Fprintf(a.trace.w, "atomiccache op=%s key=%q result=%s section=%d shard=%d record=%d\n", op, key, traceResult(err), val.ShardSection, val.ShardIndex, val.RecordIndex)
+	a.trace.Unlock()
+}
+
+// traceGc writes line of garbage collection run to debug trace (if it is
+// enabled).
+func (a *AtomicCache) traceGc(evicted int, duration time.Duration) {
+	if a.trace == nil {
+		return
+	}
+
+	a.trace.Lock()
+	fmt.Fprintf(a.trace.w, "atomiccache op=gc evicted=%d duration=%s\n", evicted, duration)
+	a.trace.Unlock()
+}
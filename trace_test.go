@@ -0,0 +1,36 @@
+package atomiccache
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestDebugTrace(t *testing.T) {
+	var buf bytes.Buffer
+
+	cache := New(OptionDebugTrace(&buf))
+	cache.Set([]byte("key"), []byte("data"), 0)
+	cache.Get([]byte("key"))
+	cache.Get([]byte(strings.Repeat("x", 40)))
+	cache.Delete([]byte("key"))
+	cache.CollectGarbage()
+
+	expected := []string{
+		`atomiccache op=set key="key" result=ok section=1 shard=0 record=0`,
+		`atomiccache op=get key="key" result=ok section=1 shard=0 record=0`,
+		`atomiccache op=get key="` + strings.Repeat("x", 32) + `" result=not_found section=0 shard=0 record=0`,
+		`atomiccache op=delete key="key" result=ok section=1 shard=0 record=0`,
+		`atomiccache op=gc evicted=0 duration=`,
+	}
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != len(expected) {
+		t.Fatalf("%v != %v", len(lines), len(expected))
+	}
+	for i := range expected {
+		if !strings.HasPrefix(lines[i], expected[i]) {
+			t.Errorf("%q != %q", lines[i], expected[i])
+		}
+	}
+}
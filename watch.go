@@ -0,0 +1,138 @@
+package atomiccache
+
+import (
+	"sync"
+)
+
+// watchBufferSize is capacity of channel returned by Watch.
+const watchBufferSize = 16
+
+// WatchEvent describes change of watched key. Type is EventSet, EventDelete,
+// EventExpire (collected by garbage collector) or EventEvict (removed by
+// eviction policy). Data contains copy of stored data for EventSet, otherwise
+// it is nil.
+type WatchEvent struct {
+	Type string
+	Key  []byte
+	Data []byte
+}
+
+// Watch returns channel which receives events of the key (every method which
+// stores or removes the record, expiration collected by garbage collector and
+// eviction) and function which stops the watch and closes the channel. Events
+// are sent after the cache lock is released. Every watch of the same key
+// receives its own events. Events are sent without blocking, so they are
+// dropped if the channel is full (its capacity is 16).
+func (a *AtomicCache) Watch(key []byte) (<-chan WatchEvent, func()) {
+	ch := make(chan WatchEvent, watchBufferSize)
+
+	a.watchMutex.Lock()
+	a.watchers[string(key)] = append(a.watchers[string(key)], ch)
+	a.watchCount.Add(1)
+	a.watchMutex.Unlock()
+
+	var once sync.Once
+	stop := func() {
+		once.Do(func() { a.unwatch(string(key), ch) })
+	}
+
+	return ch, stop
+}
+
+// unwatch removes channel from watchers registry and closes it.
+func (a *AtomicCache) unwatch(key string, ch chan WatchEvent) {
+	a.watchMutex.Lock()
+	defer a.watchMutex.Unlock()
+
+	watchers := a.watchers[key]
+	for i := range watchers {
+		if watchers[i] == ch {
+			watchers = append(watchers[:i], watchers[i+1:]...)
+			break
+		}
+	}
+
+	if len(watchers) == 0 {
+		delete(a.watchers, key)
+	} else {
+		a.watchers[key] = watchers
+	}
+
+	a.watchCount.Add(-1)
+	close(ch)
+}
+
+// watchLocked collects event of the key if the key is watched. Data are
+// copied. Collected events are sent after the write lock is released (see
+// Unlock), so every method which modifies records notifies watchers.
+// This method is not thread safe and additional locks are required.
+func (a *AtomicCache) watchLocked(eventType string, key string, data []byte) {
+	if a.watchCount.Load() == 0 {
+		return
+	}
+
+	a.watchMutex.Lock()
+	_, ok := a.watchers[key]
+	a.watchMutex.Unlock()
+	if !ok {
+		return
+	}
+
+	event := WatchEvent{Type: eventType, Key: []byte(key)}
+	if data != nil {
+		event.Data = append([]byte(nil), data...)
+	}
+	a.watchEvents = append(a.watchEvents, event)
+}
+
+// watchedKeys returns list of all watched keys.
+func (a *AtomicCache) watchedKeys() []string {
+	if a.watchCount.Load() == 0 {
+		return nil
+	}
+
+	a.watchMutex.Lock()
+	defer a.watchMutex.Unlock()
+
+	keys := make([]string, 0, len(a.watchers))
+	for key := range a.watchers {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
+
+// Unlock releases the write lock and sends watch events collected while it
+// was held, so watchers are never notified under the cache lock.
+func (a *AtomicCache) Unlock() {
+	events := a.watchEvents
+	a.watchEvents = nil
+	a.RWMutex.Unlock()
+
+	for _, event := range events {
+		a.notifyWatchers(event)
+	}
+}
+
+// notifyWatchers sends event to all watches of its key. The send does not
+// block, so event is dropped for watch whose channel is full.
+func (a *AtomicCache) notifyWatchers(event WatchEvent) {
+	if a.watchCount.Load() == 0 {
+		return
+	}
+
+	a.watchMutex.Lock()
+	defer a.watchMutex.Unlock()
+
+	for _, ch := range a.watchers[string(event.Key)] {
+		event := WatchEvent{Type: event.Type, Key: append([]byte(nil), event.Key...), Data: event.Data}
+		if event.Data != nil {
+			event.Data = append([]byte(nil), event.Data...)
+		}
+
+		select {
+		case ch <- event:
+		default:
+		}
+	}
+}
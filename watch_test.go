@@ -0,0 +1,83 @@
+package atomiccache
+
+import (
+	"bytes"
+	"testing"
+	"time"
+)
+
+func TestCacheWatch(t *testing.T) {
+	cache := New()
+	events, stop := cache.Watch([]byte("key"))
+	other, stopOther := cache.Watch([]byte("key"))
+	defer stopOther()
+
+	cache.Set([]byte("key"), []byte("data"), 0)
+	cache.Set([]byte("unwatched"), []byte("data"), 0)
+	cache.Delete([]byte("key"))
+	cache.Set([]byte("key"), []byte("data"), time.Nanosecond)
+	time.Sleep(time.Millisecond)
+	cache.CollectGarbage()
+
+	for _, ch := range []<-chan WatchEvent{events, other} {
+		for _, want := range []WatchEvent{
+			{Type: EventSet, Key: []byte("key"), Data: []byte("data")},
+			{Type: EventDelete, Key: []byte("key")},
+			{Type: EventSet, Key: []byte("key"), Data: []byte("data")},
+			{Type: EventExpire, Key: []byte("key")},
+		} {
+			select {
+			case event := <-ch:
+				if event.Type != want.Type || !bytes.Equal(event.Key, want.Key) || !bytes.Equal(event.Data, want.Data) {
+					t.Errorf("%v != %v", event, want)
+				}
+			default:
+				t.Fatalf("Missing event %v", want)
+			}
+		}
+	}
+
+	stop()
+	stop()
+	if _, ok := <-events; ok {
+		t.Errorf("Expecting closed channel")
+	}
+
+	cache.Set([]byte("key"), []byte("data"), 0)
+	if len(other) != 1 {
+		t.Errorf("%v != %v", len(other), 1)
+	}
+}
+
+func TestCacheWatchMethods(t *testing.T) {
+	cache := New(OptionMaxRecords(2), OptionMaxShardsSmall(1), OptionEvictionPolicy(FIFOPolicy{}))
+	events, stop := cache.Watch([]byte("key"))
+	defer stop()
+
+	cache.Set([]byte("key"), []byte("data"), 0)
+	cache.Update([]byte("key"), func(current []byte) ([]byte, error) { return []byte("new"), nil })
+	cache.MDelete([][]byte{[]byte("key"), []byte("other")})
+	cache.Set([]byte("key"), []byte("data"), 0)
+	cache.Set([]byte("1"), []byte("data"), 0)
+	cache.Set([]byte("2"), []byte("data"), 0)
+
+	for _, want := range []WatchEvent{
+		{Type: EventSet, Key: []byte("key"), Data: []byte("data")},
+		{Type: EventSet, Key: []byte("key"), Data: []byte("new")},
+		{Type: EventDelete, Key: []byte("key")},
+		{Type: EventSet, Key: []byte("key"), Data: []byte("data")},
+		{Type: EventEvict, Key: []byte("key")},
+	} {
+		select {
+		case event := <-events:
+			if event.Type != want.Type || !bytes.Equal(event.Key, want.Key) || !bytes.Equal(event.Data, want.Data) {
+				t.Errorf("%v != %v", event, want)
+			}
+		default:
+			t.Fatalf("Missing event %v", want)
+		}
+	}
+	if len(events) != 0 {
+		t.Errorf("%v != %v", len(events), 0)
+	}
+}